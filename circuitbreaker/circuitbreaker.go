@@ -0,0 +1,325 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/provincialig/golimitless/clock"
+	"github.com/provincialig/golimitless/retrier"
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	ErrCircuitOpen = errors.New("circuit open")
+)
+
+type CircuitBreaker interface {
+	Execute(fn func() error) error
+	State() State
+	Reset()
+}
+
+type Option func(cb *myCircuitBreaker)
+
+// ConsecutiveFailures trips the breaker after n failures in a row, it defaults to 5 unless FailureRate is set.
+func ConsecutiveFailures(n int) Option {
+	return func(cb *myCircuitBreaker) {
+		cb.maxConsecutive = n
+	}
+}
+
+func FailureRate(threshold float64, window int) Option {
+	return func(cb *myCircuitBreaker) {
+		cb.rateThreshold = threshold
+		if window > 0 {
+			cb.window = make([]bool, window)
+		}
+	}
+}
+
+func CoolDown(d time.Duration) Option {
+	return func(cb *myCircuitBreaker) {
+		cb.coolDown = d
+	}
+}
+
+func HalfOpenMaxProbes(n int) Option {
+	return func(cb *myCircuitBreaker) {
+		if n > 0 {
+			cb.maxProbes = n
+		}
+	}
+}
+
+func OnStateChange(fn func(from State, to State)) Option {
+	return func(cb *myCircuitBreaker) {
+		cb.onStateChange = fn
+	}
+}
+
+func WithClock(c clock.Clock) Option {
+	return func(cb *myCircuitBreaker) {
+		cb.clock = clock.OrDefault(c)
+	}
+}
+
+type myCircuitBreaker struct {
+	mut   sync.Mutex
+	clock clock.Clock
+
+	state      State
+	generation uint64
+	openedAt   time.Time
+
+	maxConsecutive int
+	consecutive    int
+
+	rateThreshold float64
+	window        []bool
+	windowPos     int
+	windowCount   int
+	windowFails   int
+
+	coolDown time.Duration
+
+	maxProbes     int
+	probes        int
+	probeSuccess  int
+	onStateChange func(from State, to State)
+}
+
+func (cb *myCircuitBreaker) setStateUnsafe(state State, now time.Time) func() {
+	if cb.state == state {
+		return func() {}
+	}
+
+	from := cb.state
+
+	cb.state = state
+	cb.generation++
+	cb.consecutive = 0
+	cb.probes = 0
+	cb.probeSuccess = 0
+	cb.windowPos = 0
+	cb.windowCount = 0
+	cb.windowFails = 0
+
+	if state == StateOpen {
+		cb.openedAt = now
+	}
+
+	return func() {
+		if cb.onStateChange != nil {
+			cb.onStateChange(from, state)
+		}
+	}
+}
+
+func (cb *myCircuitBreaker) refreshUnsafe(now time.Time) func() {
+	if cb.state == StateOpen && !now.Before(cb.openedAt.Add(cb.coolDown)) {
+		return cb.setStateUnsafe(StateHalfOpen, now)
+	}
+	return func() {}
+}
+
+func (cb *myCircuitBreaker) recordUnsafe(success bool) bool {
+	if success {
+		cb.consecutive = 0
+	} else {
+		cb.consecutive++
+	}
+
+	if cb.maxConsecutive > 0 && cb.consecutive >= cb.maxConsecutive {
+		return true
+	}
+
+	if len(cb.window) == 0 {
+		return false
+	}
+
+	if cb.windowCount == len(cb.window) {
+		if !cb.window[cb.windowPos] {
+			cb.windowFails--
+		}
+	} else {
+		cb.windowCount++
+	}
+
+	cb.window[cb.windowPos] = success
+	cb.windowPos = (cb.windowPos + 1) % len(cb.window)
+
+	if !success {
+		cb.windowFails++
+	}
+
+	return cb.windowCount == len(cb.window) && float64(cb.windowFails)/float64(cb.windowCount) >= cb.rateThreshold
+}
+
+func (cb *myCircuitBreaker) before() (uint64, error) {
+	cb.mut.Lock()
+
+	notify := cb.refreshUnsafe(cb.clock.Now())
+
+	var err error
+
+	switch cb.state {
+	case StateOpen:
+		err = ErrCircuitOpen
+	case StateHalfOpen:
+		if cb.probes >= cb.maxProbes {
+			err = ErrCircuitOpen
+		} else {
+			cb.probes++
+		}
+	}
+
+	generation := cb.generation
+
+	cb.mut.Unlock()
+
+	notify()
+
+	return generation, err
+}
+
+func (cb *myCircuitBreaker) after(generation uint64, success bool) {
+	cb.mut.Lock()
+
+	notify := func() {}
+
+	if generation == cb.generation {
+		switch cb.state {
+		case StateClosed:
+			if cb.recordUnsafe(success) {
+				notify = cb.setStateUnsafe(StateOpen, cb.clock.Now())
+			}
+		case StateHalfOpen:
+			if !success {
+				notify = cb.setStateUnsafe(StateOpen, cb.clock.Now())
+			} else {
+				cb.probeSuccess++
+				if cb.probeSuccess >= cb.maxProbes {
+					notify = cb.setStateUnsafe(StateClosed, cb.clock.Now())
+				}
+			}
+		}
+	}
+
+	cb.mut.Unlock()
+
+	notify()
+}
+
+func (cb *myCircuitBreaker) Execute(fn func() error) error {
+	generation, err := cb.before()
+	if err != nil {
+		return err
+	}
+
+	// A panic counts as a failure, so a half-open probe slot is always given back
+	defer func() {
+		if r := recover(); r != nil {
+			cb.after(generation, false)
+			panic(r)
+		}
+	}()
+
+	err = fn()
+	cb.after(generation, err == nil)
+
+	return err
+}
+
+func (cb *myCircuitBreaker) State() State {
+	cb.mut.Lock()
+	notify := cb.refreshUnsafe(cb.clock.Now())
+	state := cb.state
+	cb.mut.Unlock()
+
+	notify()
+
+	return state
+}
+
+func (cb *myCircuitBreaker) Reset() {
+	cb.mut.Lock()
+	notify := cb.setStateUnsafe(StateClosed, cb.clock.Now())
+	cb.consecutive = 0
+	cb.windowPos = 0
+	cb.windowCount = 0
+	cb.windowFails = 0
+	cb.mut.Unlock()
+
+	notify()
+}
+
+func New(opts ...Option) CircuitBreaker {
+	cb := &myCircuitBreaker{
+		clock:          clock.New(),
+		maxConsecutive: -1,
+		coolDown:       30 * time.Second,
+		maxProbes:      1,
+	}
+
+	for _, opt := range opts {
+		opt(cb)
+	}
+
+	if cb.maxConsecutive < 0 {
+		cb.maxConsecutive = 0
+		if len(cb.window) == 0 {
+			cb.maxConsecutive = 5
+		}
+	}
+
+	return cb
+}
+
+type breakerRetrier struct {
+	r  retrier.Retrier
+	cb CircuitBreaker
+}
+
+func (br breakerRetrier) Run(ctx context.Context, fn func() error) error {
+	return br.RunContext(ctx, func(context.Context, int) error {
+		return fn()
+	})
+}
+
+func (br breakerRetrier) RunContext(ctx context.Context, fn func(ctx context.Context, attempt int) error) error {
+	return br.r.RunContext(ctx, func(ctx context.Context, attempt int) error {
+		err := br.cb.Execute(func() error {
+			return fn(ctx, attempt)
+		})
+		if errors.Is(err, ErrCircuitOpen) {
+			return retrier.Permanent(err)
+		}
+		return err
+	})
+}
+
+func Wrap(r retrier.Retrier, cb CircuitBreaker) retrier.Retrier {
+	return breakerRetrier{r: r, cb: cb}
+}
@@ -0,0 +1,269 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/circuitbreaker"
+	"github.com/provincialig/golimitless/clock"
+	"github.com/provincialig/golimitless/retrier"
+)
+
+var errFail = errors.New("fail")
+
+func Test_ConsecutiveFailures(t *testing.T) {
+	cb := circuitbreaker.New(circuitbreaker.ConsecutiveFailures(3), circuitbreaker.CoolDown(time.Hour))
+
+	for range 2 {
+		_ = cb.Execute(func() error { return errFail })
+	}
+	_ = cb.Execute(func() error { return nil })
+
+	for range 2 {
+		_ = cb.Execute(func() error { return errFail })
+	}
+
+	if cb.State() != circuitbreaker.StateClosed {
+		t.Fatalf("expected closed, got %v", cb.State())
+	}
+
+	_ = cb.Execute(func() error { return errFail })
+
+	if cb.State() != circuitbreaker.StateOpen {
+		t.Fatalf("expected open, got %v", cb.State())
+	}
+
+	called := false
+	err := cb.Execute(func() error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	if called {
+		t.Fatal("fn must not be called when the circuit is open")
+	}
+}
+
+func Test_FailureRate(t *testing.T) {
+	cb := circuitbreaker.New(
+		circuitbreaker.FailureRate(0.5, 4),
+		circuitbreaker.CoolDown(time.Hour),
+	)
+
+	results := []error{errFail, nil, errFail}
+	for _, res := range results {
+		_ = cb.Execute(func() error { return res })
+	}
+
+	if cb.State() != circuitbreaker.StateClosed {
+		t.Fatalf("expected closed before the window is full, got %v", cb.State())
+	}
+
+	_ = cb.Execute(func() error { return nil })
+
+	if cb.State() != circuitbreaker.StateOpen {
+		t.Fatalf("expected open at 50%% failure rate, got %v", cb.State())
+	}
+}
+
+func Test_FailureRateOnly(t *testing.T) {
+	cb := circuitbreaker.New(
+		circuitbreaker.FailureRate(0.9, 10),
+		circuitbreaker.CoolDown(time.Hour),
+	)
+
+	// More than the default consecutive failures, but the window is not full yet
+	for range 6 {
+		_ = cb.Execute(func() error { return errFail })
+	}
+
+	if cb.State() != circuitbreaker.StateClosed {
+		t.Fatalf("expected closed, got %v", cb.State())
+	}
+}
+
+func Test_HalfOpen(t *testing.T) {
+	transitions := []string{}
+	var mut sync.Mutex
+
+	cb := circuitbreaker.New(
+		circuitbreaker.ConsecutiveFailures(1),
+		circuitbreaker.CoolDown(20*time.Millisecond),
+		circuitbreaker.HalfOpenMaxProbes(2),
+		circuitbreaker.OnStateChange(func(from circuitbreaker.State, to circuitbreaker.State) {
+			mut.Lock()
+			transitions = append(transitions, from.String()+"->"+to.String())
+			mut.Unlock()
+		}),
+	)
+
+	_ = cb.Execute(func() error { return errFail })
+
+	time.Sleep(30 * time.Millisecond)
+
+	if cb.State() != circuitbreaker.StateHalfOpen {
+		t.Fatalf("expected half-open, got %v", cb.State())
+	}
+
+	_ = cb.Execute(func() error { return errFail })
+
+	if cb.State() != circuitbreaker.StateOpen {
+		t.Fatalf("expected open after failed probe, got %v", cb.State())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	var wg sync.WaitGroup
+
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = cb.Execute(func() error {
+				started <- struct{}{}
+				<-release
+				return nil
+			})
+		}()
+	}
+
+	<-started
+	<-started
+
+	if err := cb.Execute(func() error { return nil }); !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		t.Fatalf("expected probes limit to reject call, got %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if cb.State() != circuitbreaker.StateClosed {
+		t.Fatalf("expected closed after successful probes, got %v", cb.State())
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	expected := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(expected) {
+		t.Fatalf("unexpected transitions: %v", transitions)
+	}
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Fatalf("unexpected transitions: %v", transitions)
+		}
+	}
+}
+
+func Test_Reset(t *testing.T) {
+	cb := circuitbreaker.New(circuitbreaker.ConsecutiveFailures(1), circuitbreaker.CoolDown(time.Hour))
+
+	_ = cb.Execute(func() error { return errFail })
+	if cb.State() != circuitbreaker.StateOpen {
+		t.Fatalf("expected open, got %v", cb.State())
+	}
+
+	cb.Reset()
+
+	if cb.State() != circuitbreaker.StateClosed {
+		t.Fatalf("expected closed after reset, got %v", cb.State())
+	}
+}
+
+func Test_WrapRetrier(t *testing.T) {
+	cb := circuitbreaker.New(circuitbreaker.ConsecutiveFailures(3), circuitbreaker.CoolDown(time.Hour))
+	r := circuitbreaker.Wrap(retrier.New(retrier.NO_DELAY, 10), cb)
+
+	calls := 0
+	err := r.Run(context.Background(), func() error {
+		calls++
+		return errFail
+	})
+	if !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected 3 calls before opening, got %d", calls)
+	}
+
+	err = r.Run(context.Background(), func() error {
+		calls++
+		return nil
+	})
+	if !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected no call while open, got %d", calls)
+	}
+}
+
+func Test_WithClock(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	cb := circuitbreaker.New(
+		circuitbreaker.ConsecutiveFailures(1),
+		circuitbreaker.CoolDown(time.Minute),
+		circuitbreaker.WithClock(c),
+	)
+
+	_ = cb.Execute(func() error { return errFail })
+
+	c.Advance(59 * time.Second)
+
+	if cb.State() != circuitbreaker.StateOpen {
+		t.Fatalf("expected open, got %v", cb.State())
+	}
+
+	c.Advance(time.Second)
+
+	if cb.State() != circuitbreaker.StateHalfOpen {
+		t.Fatalf("expected half-open, got %v", cb.State())
+	}
+}
+
+func Test_PanickingProbe(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	cb := circuitbreaker.New(
+		circuitbreaker.ConsecutiveFailures(1),
+		circuitbreaker.CoolDown(time.Minute),
+		circuitbreaker.WithClock(c),
+	)
+
+	_ = cb.Execute(func() error { return errFail })
+	c.Advance(time.Minute)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("expected the panic to be propagated, got %v", r)
+			}
+		}()
+		_ = cb.Execute(func() error { panic("boom") })
+	}()
+
+	if cb.State() != circuitbreaker.StateOpen {
+		t.Fatalf("expected the panicking probe to reopen the breaker, got %v", cb.State())
+	}
+
+	c.Advance(time.Minute)
+
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("expected a new probe to be allowed, got %v", err)
+	}
+
+	if cb.State() != circuitbreaker.StateClosed {
+		t.Fatalf("expected closed, got %v", cb.State())
+	}
+}
@@ -0,0 +1,27 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/clock"
+)
+
+func Test_RealClock(t *testing.T) {
+	c := clock.New()
+
+	start := c.Now()
+
+	timer := c.NewTimer(10 * time.Millisecond)
+	<-timer.C()
+
+	if c.Now().Sub(start) < 10*time.Millisecond {
+		t.Fatal("timer fired too early")
+	}
+
+	ticker := c.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+
+	<-ticker.C()
+	<-ticker.C()
+}
@@ -0,0 +1,214 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+type fakeWaiter struct {
+	fake *Fake
+
+	ch       chan time.Time
+	deadline time.Time
+	period   time.Duration
+	active   bool
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.ch
+}
+
+func (w *fakeWaiter) Stop() bool {
+	w.fake.mut.Lock()
+	defer w.fake.mut.Unlock()
+
+	return w.fake.removeUnsafe(w)
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.fake.mut.Lock()
+	defer w.fake.mut.Unlock()
+
+	wasActive := w.fake.removeUnsafe(w)
+	w.deadline = w.fake.now.Add(d)
+	w.fake.addUnsafe(w)
+
+	return wasActive
+}
+
+type fakeTicker struct {
+	*fakeWaiter
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeWaiter.Stop()
+}
+
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	t.fake.mut.Lock()
+	defer t.fake.mut.Unlock()
+
+	t.fake.removeUnsafe(t.fakeWaiter)
+	t.period = d
+	t.deadline = t.fake.now.Add(d)
+	t.fake.addUnsafe(t.fakeWaiter)
+}
+
+type Fake struct {
+	mut  sync.Mutex
+	cond *sync.Cond
+
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+func (f *Fake) addUnsafe(w *fakeWaiter) {
+	w.active = true
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+}
+
+func (f *Fake) removeUnsafe(w *fakeWaiter) bool {
+	if !w.active {
+		return false
+	}
+
+	w.active = false
+
+	for i, el := range f.waiters {
+		if el == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			break
+		}
+	}
+
+	f.cond.Broadcast()
+
+	return true
+}
+
+func (f *Fake) newWaiter(d time.Duration, period time.Duration) *fakeWaiter {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	w := &fakeWaiter{
+		fake:     f,
+		ch:       make(chan time.Time, 1),
+		deadline: f.now.Add(d),
+		period:   period,
+	}
+
+	if d <= 0 && period == 0 {
+		w.ch <- f.now
+		return w
+	}
+
+	f.addUnsafe(w)
+
+	return w
+}
+
+func (f *Fake) Now() time.Time {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.newWaiter(d, 0).ch
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.newWaiter(d, 0)
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.newWaiter(d, d)}
+}
+
+func (f *Fake) nextUnsafe(until time.Time) *fakeWaiter {
+	var next *fakeWaiter
+
+	for _, w := range f.waiters {
+		if w.deadline.After(until) {
+			continue
+		}
+		if next == nil || w.deadline.Before(next.deadline) {
+			next = w
+		}
+	}
+
+	return next
+}
+
+func (f *Fake) setUnsafe(t time.Time) {
+	for {
+		w := f.nextUnsafe(t)
+		if w == nil {
+			break
+		}
+
+		if w.deadline.After(f.now) {
+			f.now = w.deadline
+		}
+
+		select {
+		case w.ch <- f.now:
+		default:
+		}
+
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+		} else {
+			f.removeUnsafe(w)
+		}
+	}
+
+	if t.After(f.now) {
+		f.now = t
+	}
+}
+
+func (f *Fake) Set(t time.Time) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.setUnsafe(t)
+}
+
+func (f *Fake) Advance(d time.Duration) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.setUnsafe(f.now.Add(d))
+}
+
+func (f *Fake) Waiters() int {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	return len(f.waiters)
+}
+
+func (f *Fake) BlockUntil(n int) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mut)
+	return f
+}
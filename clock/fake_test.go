@@ -0,0 +1,137 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fired(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func Test_FakeNow(t *testing.T) {
+	c := clock.NewFake(epoch)
+
+	if !c.Now().Equal(epoch) {
+		t.Fatalf("unexpected now: %v", c.Now())
+	}
+
+	c.Advance(time.Minute)
+
+	if !c.Now().Equal(epoch.Add(time.Minute)) {
+		t.Fatalf("unexpected now: %v", c.Now())
+	}
+}
+
+func Test_FakeTimer(t *testing.T) {
+	c := clock.NewFake(epoch)
+
+	timer := c.NewTimer(time.Second)
+	after := c.After(2 * time.Second)
+
+	c.Advance(999 * time.Millisecond)
+
+	if _, ok := fired(timer.C()); ok {
+		t.Fatal("timer fired too early")
+	}
+
+	c.Advance(time.Millisecond)
+
+	if at, ok := fired(timer.C()); !ok || !at.Equal(epoch.Add(time.Second)) {
+		t.Fatalf("expected timer to fire at 1s, got %v %v", at, ok)
+	}
+
+	if _, ok := fired(after); ok {
+		t.Fatal("after fired too early")
+	}
+
+	c.Advance(time.Second)
+
+	if _, ok := fired(after); !ok {
+		t.Fatal("expected after to fire")
+	}
+
+	if c.Waiters() != 0 {
+		t.Fatalf("expected no waiters, got %d", c.Waiters())
+	}
+}
+
+func Test_FakeTimerStopReset(t *testing.T) {
+	c := clock.NewFake(epoch)
+
+	timer := c.NewTimer(time.Second)
+
+	if !timer.Stop() {
+		t.Fatal("expected Stop to report an active timer")
+	}
+
+	c.Advance(time.Second)
+
+	if _, ok := fired(timer.C()); ok {
+		t.Fatal("stopped timer fired")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Fatal("expected Reset to report an inactive timer")
+	}
+
+	c.Advance(time.Second)
+
+	if _, ok := fired(timer.C()); !ok {
+		t.Fatal("expected reset timer to fire")
+	}
+}
+
+func Test_FakeTicker(t *testing.T) {
+	c := clock.NewFake(epoch)
+
+	ticker := c.NewTicker(time.Second)
+
+	ticks := 0
+	for range 3 {
+		c.Advance(time.Second)
+		if _, ok := fired(ticker.C()); ok {
+			ticks++
+		}
+	}
+
+	if ticks != 3 {
+		t.Fatalf("expected 3 ticks, got %d", ticks)
+	}
+
+	ticker.Stop()
+	c.Advance(time.Second)
+
+	if _, ok := fired(ticker.C()); ok {
+		t.Fatal("stopped ticker fired")
+	}
+}
+
+func Test_FakeBlockUntil(t *testing.T) {
+	c := clock.NewFake(epoch)
+
+	done := make(chan struct{})
+
+	go func() {
+		<-c.After(time.Second)
+		close(done)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not released")
+	}
+}
@@ -3,6 +3,8 @@ package expireset
 import (
 	"sync"
 	"time"
+
+	"github.com/provincialig/golimitless/clock"
 )
 
 type ExpireSet[T comparable] interface {
@@ -15,8 +17,9 @@ type ExpireSet[T comparable] interface {
 }
 
 type myExpireSet[T comparable] struct {
-	m   map[T]time.Time
-	mut sync.Mutex
+	m     map[T]time.Time
+	mut   sync.Mutex
+	clock clock.Clock
 }
 
 func (es *myExpireSet[T]) getUnsafe(value T) (time.Time, bool) {
@@ -27,7 +30,7 @@ func (es *myExpireSet[T]) getUnsafe(value T) (time.Time, bool) {
 		return zero, false
 	}
 
-	if time.Now().After(v) {
+	if es.clock.Now().After(v) {
 		delete(es.m, value)
 		return zero, false
 	}
@@ -39,7 +42,7 @@ func (es *myExpireSet[T]) Add(value T, retain time.Duration) {
 	es.mut.Lock()
 	defer es.mut.Unlock()
 
-	es.m[value] = time.Now().Add(retain)
+	es.m[value] = es.clock.Now().Add(retain)
 }
 
 func (es *myExpireSet[T]) Has(value T) (bool, time.Time) {
@@ -96,5 +99,12 @@ func (es *myExpireSet[T]) IsEmpty() bool {
 }
 
 func New[T comparable]() ExpireSet[T] {
-	return &myExpireSet[T]{m: map[T]time.Time{}}
+	return NewWithClock[T](clock.New())
+}
+
+func NewWithClock[T comparable](c clock.Clock) ExpireSet[T] {
+	return &myExpireSet[T]{
+		m:     map[T]time.Time{},
+		clock: clock.OrDefault(c),
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/provincialig/golimitless/clock"
 	"github.com/provincialig/golimitless/expireset"
 )
 
@@ -72,3 +73,25 @@ func Test_ExpireTime(t *testing.T) {
 		t.Fatal("ExpireTime should be in the future")
 	}
 }
+
+func Test_FakeClockExpire(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	es := expireset.NewWithClock[int](c)
+	es.Add(1, time.Minute)
+	es.Add(2, time.Hour)
+
+	c.Advance(time.Minute + time.Nanosecond)
+
+	if ok, _ := es.Has(1); ok {
+		t.Fatal("Expire set should not have element 1")
+	}
+
+	if ok, _ := es.Has(2); !ok {
+		t.Fatal("Expire set should have element 2")
+	}
+
+	if size := es.Size(); size != 1 {
+		t.Fatalf("Size: %d", size)
+	}
+}
@@ -0,0 +1,326 @@
+package history
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/provincialig/golimitless/stack"
+)
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+	ErrInTransaction = errors.New("transaction in progress")
+	ErrNoTransaction = errors.New("no transaction in progress")
+)
+
+type Command interface {
+	Do() error
+	Undo() error
+}
+
+type funcCommand struct {
+	do   func() error
+	undo func() error
+}
+
+func (c funcCommand) Do() error {
+	return c.do()
+}
+
+func (c funcCommand) Undo() error {
+	return c.undo()
+}
+
+func Func(do func() error, undo func() error) Command {
+	return funcCommand{do: do, undo: undo}
+}
+
+// group runs its commands as one, compensating the ones already applied when one fails.
+type group []Command
+
+func (g group) Do() error {
+	for i, cmd := range g {
+		if err := cmd.Do(); err != nil {
+			return errors.Join(err, g[:i].undo())
+		}
+	}
+	return nil
+}
+
+func (g group) Undo() error {
+	for i := len(g) - 1; i >= 0; i-- {
+		if err := g[i].Undo(); err != nil {
+			return errors.Join(err, g[i+1:].Do())
+		}
+	}
+	return nil
+}
+
+func (g group) undo() error {
+	var errs []error
+	for i := len(g) - 1; i >= 0; i-- {
+		if err := g[i].Undo(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type Event int
+
+const (
+	EventDo Event = iota
+	EventUndo
+	EventRedo
+	EventCommit
+	EventRollback
+	EventClear
+)
+
+func (e Event) String() string {
+	switch e {
+	case EventDo:
+		return "do"
+	case EventUndo:
+		return "undo"
+	case EventRedo:
+		return "redo"
+	case EventCommit:
+		return "commit"
+	case EventRollback:
+		return "rollback"
+	case EventClear:
+		return "clear"
+	default:
+		return "unknown"
+	}
+}
+
+type History interface {
+	Do(cmd Command) error
+	Undo() error
+	Redo() error
+	CanUndo() bool
+	CanRedo() bool
+	Begin() error
+	Commit() error
+	Rollback() error
+	Clear()
+}
+
+type Option func(h *myHistory)
+
+// Limit keeps at most n undoable commands, dropping the oldest ones.
+func Limit(n int) Option {
+	return func(h *myHistory) {
+		h.limit = n
+	}
+}
+
+// OnChange is called after every change, outside of the history lock.
+func OnChange(fn func(e Event)) Option {
+	return func(h *myHistory) {
+		h.onChange = fn
+	}
+}
+
+type myHistory struct {
+	mut sync.Mutex
+
+	undo stack.Stack[Command]
+	redo stack.Stack[Command]
+
+	// Only the top undoable commands can be undone, the ones below are past the limit
+	// and get trimmed once the undo stack reaches twice the limit
+	undoable int
+
+	tx       group
+	inTx     bool
+	limit    int
+	onChange func(e Event)
+}
+
+func (h *myHistory) notify(e Event) {
+	if h.onChange != nil {
+		h.onChange(e)
+	}
+}
+
+func (h *myHistory) recordUnsafe(cmd Command) {
+	h.undo.Push(cmd)
+	h.redo.Clear()
+
+	h.undoable++
+	if h.limit > 0 {
+		h.undoable = min(h.undoable, h.limit)
+	}
+
+	if h.limit > 0 && h.undo.Size() >= 2*h.limit {
+		kept := h.undo.PeekN(h.limit)
+		slices.Reverse(kept)
+
+		h.undo.Clear()
+		h.undo.PushAll(kept...)
+	}
+}
+
+func (h *myHistory) Do(cmd Command) error {
+	h.mut.Lock()
+
+	if err := cmd.Do(); err != nil {
+		h.mut.Unlock()
+		return err
+	}
+
+	if h.inTx {
+		h.tx = append(h.tx, cmd)
+	} else {
+		h.recordUnsafe(cmd)
+	}
+
+	h.mut.Unlock()
+
+	h.notify(EventDo)
+
+	return nil
+}
+
+func (h *myHistory) move(from, to stack.Stack[Command], step int, empty error, apply func(Command) error, e Event) error {
+	h.mut.Lock()
+
+	if h.inTx {
+		h.mut.Unlock()
+		return ErrInTransaction
+	}
+
+	if step < 0 && h.undoable == 0 {
+		h.mut.Unlock()
+		return empty
+	}
+
+	cmd, ok := from.TryPop()
+	if !ok {
+		h.mut.Unlock()
+		return empty
+	}
+
+	if err := apply(cmd); err != nil {
+		from.Push(cmd)
+		h.mut.Unlock()
+		return err
+	}
+
+	to.Push(cmd)
+	h.undoable += step
+
+	h.mut.Unlock()
+
+	h.notify(e)
+
+	return nil
+}
+
+func (h *myHistory) Undo() error {
+	return h.move(h.undo, h.redo, -1, ErrNothingToUndo, Command.Undo, EventUndo)
+}
+
+func (h *myHistory) Redo() error {
+	return h.move(h.redo, h.undo, 1, ErrNothingToRedo, Command.Do, EventRedo)
+}
+
+func (h *myHistory) CanUndo() bool {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	return !h.inTx && h.undoable > 0
+}
+
+func (h *myHistory) CanRedo() bool {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	return !h.inTx && !h.redo.IsEmpty()
+}
+
+func (h *myHistory) Begin() error {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	if h.inTx {
+		return ErrInTransaction
+	}
+
+	h.inTx = true
+
+	return nil
+}
+
+func (h *myHistory) Commit() error {
+	h.mut.Lock()
+
+	if !h.inTx {
+		h.mut.Unlock()
+		return ErrNoTransaction
+	}
+
+	if len(h.tx) > 0 {
+		h.recordUnsafe(h.tx)
+	}
+
+	h.tx = nil
+	h.inTx = false
+
+	h.mut.Unlock()
+
+	h.notify(EventCommit)
+
+	return nil
+}
+
+func (h *myHistory) Rollback() error {
+	h.mut.Lock()
+
+	if !h.inTx {
+		h.mut.Unlock()
+		return ErrNoTransaction
+	}
+
+	err := h.tx.undo()
+
+	h.tx = nil
+	h.inTx = false
+
+	h.mut.Unlock()
+
+	h.notify(EventRollback)
+
+	return err
+}
+
+// Clear forgets every command, including the ones of an open transaction, without undoing them.
+func (h *myHistory) Clear() {
+	h.mut.Lock()
+	h.undo.Clear()
+	h.redo.Clear()
+	h.undoable = 0
+	h.tx = nil
+	h.inTx = false
+	h.mut.Unlock()
+
+	h.notify(EventClear)
+}
+
+func New(opts ...Option) History {
+	h := &myHistory{
+		undo: stack.New[Command](),
+		redo: stack.New[Command](),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
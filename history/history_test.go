@@ -0,0 +1,257 @@
+package history_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/provincialig/golimitless/history"
+)
+
+func add(total *int, n int) history.Command {
+	return history.Func(
+		func() error { *total += n; return nil },
+		func() error { *total -= n; return nil },
+	)
+}
+
+func Test_DoUndoRedo(t *testing.T) {
+	total := 0
+	h := history.New()
+
+	if err := h.Undo(); !errors.Is(err, history.ErrNothingToUndo) {
+		t.Fatalf("Expected ErrNothingToUndo, got %v", err)
+	}
+
+	_ = h.Do(add(&total, 1))
+	_ = h.Do(add(&total, 2))
+
+	if total != 3 || !h.CanUndo() || h.CanRedo() {
+		t.Fatalf("Total: %d", total)
+	}
+
+	if err := h.Undo(); err != nil || total != 1 {
+		t.Fatalf("Undo: %d %v", total, err)
+	}
+
+	if err := h.Redo(); err != nil || total != 3 {
+		t.Fatalf("Redo: %d %v", total, err)
+	}
+
+	_ = h.Undo()
+	_ = h.Do(add(&total, 10))
+
+	if total != 11 || h.CanRedo() {
+		t.Fatalf("Expected Do to discard redo, total %d", total)
+	}
+
+	if err := h.Redo(); !errors.Is(err, history.ErrNothingToRedo) {
+		t.Fatalf("Expected ErrNothingToRedo, got %v", err)
+	}
+}
+
+func Test_FailingCommand(t *testing.T) {
+	fail := errors.New("fail")
+	undone := false
+
+	h := history.New()
+
+	if err := h.Do(history.Func(func() error { return fail }, nil)); !errors.Is(err, fail) {
+		t.Fatalf("Expected fail, got %v", err)
+	}
+
+	if h.CanUndo() {
+		t.Fatal("Expected a failed command not to be recorded")
+	}
+
+	_ = h.Do(history.Func(
+		func() error { return nil },
+		func() error {
+			if !undone {
+				undone = true
+				return fail
+			}
+			return nil
+		},
+	))
+
+	if err := h.Undo(); !errors.Is(err, fail) {
+		t.Fatalf("Expected fail, got %v", err)
+	}
+
+	if !h.CanUndo() || h.CanRedo() {
+		t.Fatal("Expected the command to stay on the undo stack")
+	}
+
+	if err := h.Undo(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Limit(t *testing.T) {
+	total := 0
+	h := history.New(history.Limit(2))
+
+	for i := 1; i <= 10; i++ {
+		_ = h.Do(add(&total, i))
+	}
+
+	_ = h.Undo()
+	_ = h.Undo()
+
+	if err := h.Undo(); !errors.Is(err, history.ErrNothingToUndo) {
+		t.Fatalf("Expected ErrNothingToUndo, got %v", err)
+	}
+
+	if total != 36 || h.CanUndo() {
+		t.Fatalf("Total: %d", total)
+	}
+
+	_ = h.Redo()
+	_ = h.Redo()
+
+	if total != 55 {
+		t.Fatalf("Total: %d", total)
+	}
+
+	_ = h.Undo()
+	_ = h.Do(add(&total, 100))
+	_ = h.Undo()
+	_ = h.Undo()
+
+	if err := h.Undo(); !errors.Is(err, history.ErrNothingToUndo) {
+		t.Fatalf("Expected ErrNothingToUndo, got %v", err)
+	}
+
+	if total != 36 {
+		t.Fatalf("Total: %d", total)
+	}
+}
+
+func Test_Transaction(t *testing.T) {
+	total := 0
+	h := history.New()
+
+	if err := h.Commit(); !errors.Is(err, history.ErrNoTransaction) {
+		t.Fatalf("Expected ErrNoTransaction, got %v", err)
+	}
+
+	_ = h.Begin()
+
+	if err := h.Begin(); !errors.Is(err, history.ErrInTransaction) {
+		t.Fatalf("Expected ErrInTransaction, got %v", err)
+	}
+
+	_ = h.Do(add(&total, 1))
+	_ = h.Do(add(&total, 2))
+
+	if err := h.Undo(); !errors.Is(err, history.ErrInTransaction) {
+		t.Fatalf("Expected ErrInTransaction, got %v", err)
+	}
+
+	if err := h.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Undo(); err != nil || total != 0 {
+		t.Fatalf("Undo: %d %v", total, err)
+	}
+
+	if err := h.Redo(); err != nil || total != 3 {
+		t.Fatalf("Redo: %d %v", total, err)
+	}
+
+	_ = h.Begin()
+	_ = h.Do(add(&total, 5))
+
+	if err := h.Rollback(); err != nil || total != 3 {
+		t.Fatalf("Rollback: %d %v", total, err)
+	}
+
+	_ = h.Undo()
+
+	if total != 0 || h.CanUndo() {
+		t.Fatalf("Expected the rolled back command not to be recorded, total %d", total)
+	}
+}
+
+func Test_Transaction_CompensatesOnFailure(t *testing.T) {
+	total := 0
+	fail := errors.New("fail")
+	h := history.New()
+
+	_ = h.Begin()
+	_ = h.Do(add(&total, 1))
+	_ = h.Do(history.Func(
+		func() error { return nil },
+		func() error { return fail },
+	))
+	_ = h.Commit()
+
+	if err := h.Undo(); !errors.Is(err, fail) {
+		t.Fatalf("Expected fail, got %v", err)
+	}
+
+	if total != 1 || !h.CanUndo() {
+		t.Fatalf("Expected the group to stay applied, total %d", total)
+	}
+}
+
+func Test_ClearResetsTransaction(t *testing.T) {
+	total := 0
+	h := history.New()
+
+	_ = h.Begin()
+	_ = h.Do(add(&total, 1))
+	h.Clear()
+
+	if err := h.Commit(); !errors.Is(err, history.ErrNoTransaction) {
+		t.Fatalf("Expected ErrNoTransaction, got %v", err)
+	}
+
+	_ = h.Do(add(&total, 2))
+
+	if err := h.Undo(); err != nil || total != 1 {
+		t.Fatalf("Undo: %d %v", total, err)
+	}
+
+	if h.CanUndo() {
+		t.Fatal("Expected the cleared transaction not to be recorded")
+	}
+}
+
+func Test_OnChange(t *testing.T) {
+	total := 0
+	var events []history.Event
+
+	var h history.History
+	h = history.New(history.OnChange(func(e history.Event) {
+		events = append(events, e)
+		// Called outside of the lock
+		_ = h.CanUndo()
+	}))
+
+	_ = h.Do(add(&total, 1))
+	_ = h.Undo()
+	_ = h.Redo()
+	_ = h.Begin()
+	_ = h.Do(add(&total, 2))
+	_ = h.Commit()
+	_ = h.Begin()
+	_ = h.Rollback()
+	h.Clear()
+
+	expected := []history.Event{
+		history.EventDo,
+		history.EventUndo,
+		history.EventRedo,
+		history.EventDo,
+		history.EventCommit,
+		history.EventRollback,
+		history.EventClear,
+	}
+
+	if !slices.Equal(events, expected) {
+		t.Fatalf("Events: %v", events)
+	}
+}
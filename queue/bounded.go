@@ -0,0 +1,227 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type OverflowPolicy int
+
+const (
+	OverflowBlock OverflowPolicy = iota
+	OverflowDropNewest
+	OverflowDropOldest
+	OverflowError
+)
+
+var (
+	ErrFull = errors.New("queue full")
+)
+
+type BoundedOption func(c *boundedConfig)
+
+type boundedConfig struct {
+	policy OverflowPolicy
+}
+
+// WithOverflowPolicy selects what Enqueue does on a full queue, the default is OverflowBlock.
+func WithOverflowPolicy(policy OverflowPolicy) BoundedOption {
+	return func(c *boundedConfig) {
+		c.policy = policy
+	}
+}
+
+type BoundedQueue[T any] interface {
+	Enqueue(ctx context.Context, el T) error
+	TryEnqueue(el T) bool
+	TryDequeue() (T, bool)
+	Dequeue(ctx context.Context) (T, error)
+	Clear()
+	IsEmpty() bool
+	Size() int
+	Cap() int
+}
+
+type boundedQueue[T any] struct {
+	mut      *sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+
+	policy OverflowPolicy
+
+	items *ring[T]
+}
+
+func (q *boundedQueue[T]) pushUnsafe(el T) {
+	q.items.push(el)
+	q.notEmpty.Signal()
+}
+
+func (q *boundedQueue[T]) popUnsafe() (T, bool) {
+	el, ok := q.items.pop()
+	if ok {
+		q.notFull.Signal()
+	}
+	return el, ok
+}
+
+func (q *boundedQueue[T]) wakeOnDone(ctx context.Context) func() {
+	stop := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			q.mut.Lock()
+			q.notEmpty.Broadcast()
+			q.notFull.Broadcast()
+			q.mut.Unlock()
+		case <-stop:
+			return
+		}
+	}()
+
+	return func() { close(stop) }
+}
+
+func (q *boundedQueue[T]) Enqueue(ctx context.Context, el T) error {
+	if ctx.Err() != nil {
+		return contextError(ctx)
+	}
+
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if !q.items.full() {
+		q.pushUnsafe(el)
+		return nil
+	}
+
+	switch q.policy {
+	case OverflowDropNewest:
+		return nil
+	case OverflowDropOldest:
+		q.popUnsafe()
+		q.pushUnsafe(el)
+		return nil
+	case OverflowError:
+		return ErrFull
+	}
+
+	stop := q.wakeOnDone(ctx)
+	defer stop()
+
+	for q.items.full() {
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
+
+		q.notFull.Wait()
+	}
+
+	q.pushUnsafe(el)
+
+	return nil
+}
+
+func (q *boundedQueue[T]) TryEnqueue(el T) bool {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if !q.items.full() {
+		q.pushUnsafe(el)
+		return true
+	}
+
+	if q.policy == OverflowDropOldest {
+		q.popUnsafe()
+		q.pushUnsafe(el)
+		return true
+	}
+
+	return false
+}
+
+func (q *boundedQueue[T]) TryDequeue() (T, bool) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	return q.popUnsafe()
+}
+
+func (q *boundedQueue[T]) Dequeue(ctx context.Context) (T, error) {
+	if ctx.Err() != nil {
+		var zero T
+		return zero, contextError(ctx)
+	}
+
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if item, ok := q.popUnsafe(); ok {
+		return item, nil
+	}
+
+	stop := q.wakeOnDone(ctx)
+	defer stop()
+
+	for {
+		if item, ok := q.popUnsafe(); ok {
+			return item, nil
+		}
+
+		if ctx.Err() != nil {
+			var zero T
+			return zero, contextError(ctx)
+		}
+
+		q.notEmpty.Wait()
+	}
+}
+
+func (q *boundedQueue[T]) Clear() {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	q.items.clear()
+
+	q.notFull.Broadcast()
+}
+
+func (q *boundedQueue[T]) Size() int {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	return q.items.len()
+}
+
+func (q *boundedQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+func (q *boundedQueue[T]) Cap() int {
+	return len(q.items.buf)
+}
+
+func NewBounded[T any](capacity int, opts ...BoundedOption) BoundedQueue[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	config := boundedConfig{policy: OverflowBlock}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	var mut sync.Mutex
+
+	q := &boundedQueue[T]{
+		mut:      &mut,
+		notEmpty: sync.NewCond(&mut),
+		notFull:  sync.NewCond(&mut),
+		policy:   config.policy,
+		items:    newRing[T](capacity),
+	}
+
+	return q
+}
@@ -0,0 +1,175 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/queue"
+)
+
+func Test_BoundedEnqueueDequeue(t *testing.T) {
+	q := queue.NewBounded[int](3)
+
+	for i := range 3 {
+		if err := q.Enqueue(context.Background(), i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if q.Size() != 3 || q.Cap() != 3 {
+		t.Fatalf("expected size 3 and cap 3, got %d %d", q.Size(), q.Cap())
+	}
+
+	if q.TryEnqueue(3) {
+		t.Fatal("expected TryEnqueue to fail on a full queue")
+	}
+
+	for i := range 3 {
+		if val, err := q.Dequeue(context.Background()); err != nil || val != i {
+			t.Fatalf("expected %d, got %v %v", i, val, err)
+		}
+	}
+
+	if !q.IsEmpty() {
+		t.Fatal("expected queue to be empty")
+	}
+}
+
+func Test_BoundedEnqueueBlocking(t *testing.T) {
+	q := queue.NewBounded[int](1)
+	if err := q.Enqueue(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- q.Enqueue(context.Background(), 2)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected Enqueue to block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if val, ok := q.TryDequeue(); !ok || val != 1 {
+		t.Fatalf("expected 1, got %v %v", val, ok)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if val, ok := q.TryDequeue(); !ok || val != 2 {
+		t.Fatalf("expected 2, got %v %v", val, ok)
+	}
+}
+
+func Test_BoundedEnqueueTimeout(t *testing.T) {
+	q := queue.NewBounded[int](1)
+	if err := q.Enqueue(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := q.Enqueue(ctx, 2); !errors.Is(err, queue.ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+
+	if q.Size() != 1 {
+		t.Fatalf("expected size 1, got %d", q.Size())
+	}
+}
+
+func Test_BoundedOverflowPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	dropNewest := queue.NewBounded[int](2, queue.WithOverflowPolicy(queue.OverflowDropNewest))
+	for i := range 3 {
+		if err := dropNewest.Enqueue(ctx, i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if val, _ := dropNewest.TryDequeue(); val != 0 {
+		t.Fatalf("expected 0, got %d", val)
+	}
+	if val, _ := dropNewest.TryDequeue(); val != 1 {
+		t.Fatalf("expected 1, got %d", val)
+	}
+
+	dropOldest := queue.NewBounded[int](2, queue.WithOverflowPolicy(queue.OverflowDropOldest))
+	for i := range 3 {
+		if err := dropOldest.Enqueue(ctx, i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if !dropOldest.TryEnqueue(3) {
+		t.Fatal("expected TryEnqueue to drop the oldest element")
+	}
+	if val, _ := dropOldest.TryDequeue(); val != 2 {
+		t.Fatalf("expected 2, got %d", val)
+	}
+	if val, _ := dropOldest.TryDequeue(); val != 3 {
+		t.Fatalf("expected 3, got %d", val)
+	}
+
+	withError := queue.NewBounded[int](1, queue.WithOverflowPolicy(queue.OverflowError))
+	if err := withError.Enqueue(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := withError.Enqueue(ctx, 2); !errors.Is(err, queue.ErrFull) {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+}
+
+func Test_BoundedDequeueCancel(t *testing.T) {
+	q := queue.NewBounded[int](1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	if _, err := q.Dequeue(ctx); !errors.Is(err, queue.ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+}
+
+func Test_BoundedConcurrent(t *testing.T) {
+	q := queue.NewBounded[int](4)
+	const n = 200
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := range n {
+			if err := q.Enqueue(context.Background(), i); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := range n {
+			val, err := q.Dequeue(context.Background())
+			if err != nil || val != i {
+				t.Errorf("expected %d, got %v %v", i, val, err)
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	if !q.IsEmpty() {
+		t.Fatal("expected queue to be empty")
+	}
+}
@@ -0,0 +1,95 @@
+package queue
+
+import "context"
+
+type requeuer[T any] interface {
+	requeue(els ...T)
+}
+
+// putBack returns the items left in out, followed by pending, to the queue.
+// Queues built by New keep them at the head, other queues get them at the tail.
+func putBack[T any](q Queue[T], out chan T, pending ...T) {
+	var items []T
+
+drain:
+	for {
+		select {
+		case item := <-out:
+			items = append(items, item)
+		default:
+			break drain
+		}
+	}
+
+	items = append(items, pending...)
+
+	if len(items) == 0 {
+		return
+	}
+
+	if r, ok := q.(requeuer[T]); ok {
+		r.requeue(items...)
+		return
+	}
+
+	_ = q.EnqueueAll(items...)
+}
+
+// ToChannel pumps the queue into the returned channel until ctx is done or the queue is closed.
+// When ctx ends, the items dequeued but not read yet, including the ones buffered in the channel,
+// are put back into the queue. Over a DurableQueue the channel is unbuffered, whatever buf is,
+// so that an unread item never leaves the queue.
+func ToChannel[T any](ctx context.Context, q Queue[T], buf int) <-chan T {
+	if d, ok := q.(*durableQueue[T]); ok {
+		return d.toChannel(ctx)
+	}
+
+	out := make(chan T, max(buf, 0))
+
+	go func() {
+		defer close(out)
+
+		for {
+			item, err := q.Dequeue(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					putBack(q, out)
+				}
+				return
+			}
+
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				putBack(q, out, item)
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// FromChannel fills the returned queue with the items read from ch.
+// The queue is closed when ch is closed or ctx is done.
+func FromChannel[T any](ctx context.Context, ch <-chan T) Queue[T] {
+	q := New[T]()
+
+	go func() {
+		defer q.Close()
+
+		for {
+			select {
+			case item, ok := <-ch:
+				if !ok {
+					return
+				}
+				_ = q.Enqueue(item)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return q
+}
@@ -0,0 +1,160 @@
+package queue_test
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/queue"
+)
+
+func Test_ToChannel(t *testing.T) {
+	q := queue.New[int]()
+	enqueue(t, q, 1, 2, 3)
+	q.Close()
+
+	expected := 1
+	for val := range queue.ToChannel(context.Background(), q, 2) {
+		if val != expected {
+			t.Fatalf("expected %d, got %d", expected, val)
+		}
+		expected++
+	}
+
+	if expected != 4 {
+		t.Fatalf("expected 3 items, got %d", expected-1)
+	}
+}
+
+func Test_ToChannelCancel(t *testing.T) {
+	q := queue.New[int]()
+	enqueue(t, q, 1, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch := queue.ToChannel(ctx, q, 0)
+
+	if val := <-ch; val != 1 {
+		t.Fatalf("expected 1, got %d", val)
+	}
+
+	// Stop reading while the pump holds item 2
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	for range ch {
+	}
+
+	if val, ok := q.TryDequeue(); !ok || val != 2 {
+		t.Fatalf("expected item 2 to be put back, got %v %v", val, ok)
+	}
+}
+
+func Test_ToChannelCancelKeepsOrder(t *testing.T) {
+	t.Run("LinkedList", func(t *testing.T) {
+		testToChannelCancelKeepsOrder(t, queue.New[int]())
+	})
+
+	t.Run("RingBuffer", func(t *testing.T) {
+		testToChannelCancelKeepsOrder(t, queue.New[int](queue.WithRingBuffer(2)))
+	})
+}
+
+func testToChannelCancelKeepsOrder(t *testing.T, q queue.Queue[int]) {
+	enqueue(t, q, 1, 2, 3, 4, 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch := queue.ToChannel(ctx, q, 2)
+
+	if val := <-ch; val != 1 {
+		t.Fatalf("expected 1, got %d", val)
+	}
+
+	// 2 and 3 wait in the buffer while the pump holds 4
+	deadline := time.Now().Add(time.Second)
+	for q.Size() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+
+	for q.Size() != 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	for val := range ch {
+		t.Fatalf("expected buffered items to be put back, read %d", val)
+	}
+
+	if items := q.Snapshot(); !slices.Equal(items, []int{2, 3, 4, 5}) {
+		t.Fatalf("expected [2 3 4 5], got %v", items)
+	}
+}
+
+func Test_ToChannelNoLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for range 50 {
+		q := queue.New[int]()
+		enqueue(t, q, 1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		_ = queue.ToChannel(ctx, q, 0)
+		cancel()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("goroutine leak: %d before, %d after", before, after)
+	}
+}
+
+func Test_FromChannel(t *testing.T) {
+	ch := make(chan int)
+
+	q := queue.FromChannel(context.Background(), ch)
+
+	go func() {
+		for i := range 5 {
+			ch <- i
+		}
+		close(ch)
+	}()
+
+	expected := 0
+	for val := range q.All(context.Background()) {
+		if val != expected {
+			t.Fatalf("expected %d, got %d", expected, val)
+		}
+		expected++
+	}
+
+	if expected != 5 {
+		t.Fatalf("expected 5 items, got %d", expected)
+	}
+
+	if err := q.Enqueue(5); err != queue.ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func Test_FromChannelCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q := queue.FromChannel(ctx, make(chan int))
+	cancel()
+
+	dctx, dcancel := context.WithTimeout(context.Background(), time.Second)
+	defer dcancel()
+
+	if _, err := q.Dequeue(dctx); err != queue.ErrClosed {
+		t.Fatalf("expected ErrClosed after cancel, got %v", err)
+	}
+}
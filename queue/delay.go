@@ -0,0 +1,172 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/provincialig/golimitless/clock"
+)
+
+type DelayQueue[T any] interface {
+	EnqueueAt(el T, at time.Time) DelayHandle[T]
+	EnqueueAfter(el T, d time.Duration) DelayHandle[T]
+	TryDequeue() (T, bool)
+	Remove(h DelayHandle[T]) (T, bool)
+	Dequeue(ctx context.Context) (T, error)
+	Clear()
+	IsEmpty() bool
+	Size() int
+}
+
+type delayItem[T any] struct {
+	value T
+	at    time.Time
+}
+
+type DelayHandle[T any] struct {
+	item *Handle[delayItem[T]]
+}
+
+type delayQueue[T any] struct {
+	mut   sync.Mutex
+	clock clock.Clock
+
+	h    *priorityHeap[delayItem[T]]
+	wake chan struct{}
+}
+
+func (q *delayQueue[T]) notifyUnsafe() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+func (q *delayQueue[T]) EnqueueAt(el T, at time.Time) DelayHandle[T] {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	item := q.h.push(delayItem[T]{value: el, at: at}, q)
+
+	if item.index == 0 {
+		q.notifyUnsafe()
+	}
+
+	return DelayHandle[T]{item: item}
+}
+
+func (q *delayQueue[T]) EnqueueAfter(el T, d time.Duration) DelayHandle[T] {
+	return q.EnqueueAt(el, q.clock.Now().Add(d))
+}
+
+func (q *delayQueue[T]) dequeueUnsafe() (T, time.Duration, bool) {
+	var zero T
+
+	item, ok := q.h.peek()
+	if !ok {
+		return zero, -1, false
+	}
+
+	if wait := item.value.at.Sub(q.clock.Now()); wait > 0 {
+		return zero, wait, false
+	}
+
+	q.h.pop()
+
+	return item.value.value, 0, true
+}
+
+func (q *delayQueue[T]) TryDequeue() (T, bool) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	item, _, ok := q.dequeueUnsafe()
+	return item, ok
+}
+
+func (q *delayQueue[T]) Dequeue(ctx context.Context) (T, error) {
+	for {
+		if ctx.Err() != nil {
+			var zero T
+			return zero, contextError(ctx)
+		}
+
+		q.mut.Lock()
+		item, wait, ok := q.dequeueUnsafe()
+		wake := q.wake
+		q.mut.Unlock()
+
+		if ok {
+			return item, nil
+		}
+
+		if wait < 0 {
+			select {
+			case <-wake:
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		t := q.clock.NewTimer(wait)
+
+		select {
+		case <-t.C():
+		case <-wake:
+		case <-ctx.Done():
+		}
+
+		t.Stop()
+	}
+}
+
+func (q *delayQueue[T]) Remove(h DelayHandle[T]) (T, bool) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if !q.h.contains(h.item, q) {
+		var zero T
+		return zero, false
+	}
+
+	if h.item.index == 0 {
+		q.notifyUnsafe()
+	}
+
+	heap.Remove(q.h, h.item.index)
+
+	return h.item.value.value, true
+}
+
+func (q *delayQueue[T]) Clear() {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	q.h.clear()
+	q.notifyUnsafe()
+}
+
+func (q *delayQueue[T]) Size() int {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	return q.h.Len()
+}
+
+func (q *delayQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+func NewDelay[T any]() DelayQueue[T] {
+	return NewDelayWithClock[T](clock.New())
+}
+
+func NewDelayWithClock[T any](c clock.Clock) DelayQueue[T] {
+	return &delayQueue[T]{
+		clock: clock.OrDefault(c),
+		h: &priorityHeap[delayItem[T]]{less: func(a, b delayItem[T]) bool {
+			return a.at.Before(b.at)
+		}},
+		wake: make(chan struct{}),
+	}
+}
@@ -0,0 +1,162 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/clock"
+	"github.com/provincialig/golimitless/queue"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func Test_DelayTryDequeue(t *testing.T) {
+	c := clock.NewFake(epoch)
+	q := queue.NewDelayWithClock[string](c)
+
+	q.EnqueueAfter("b", 2*time.Second)
+	q.EnqueueAt("a", epoch.Add(time.Second))
+	q.EnqueueAfter("c", 2*time.Second)
+
+	if q.Size() != 3 {
+		t.Fatalf("expected size 3, got %d", q.Size())
+	}
+
+	if _, ok := q.TryDequeue(); ok {
+		t.Fatal("expected no item to be due")
+	}
+
+	c.Advance(time.Second)
+
+	if val, ok := q.TryDequeue(); !ok || val != "a" {
+		t.Fatalf("expected a, got %v %v", val, ok)
+	}
+
+	if _, ok := q.TryDequeue(); ok {
+		t.Fatal("expected no item to be due")
+	}
+
+	c.Advance(time.Second)
+
+	if val, ok := q.TryDequeue(); !ok || val != "b" {
+		t.Fatalf("expected b, got %v %v", val, ok)
+	}
+
+	if val, ok := q.TryDequeue(); !ok || val != "c" {
+		t.Fatalf("expected c, got %v %v", val, ok)
+	}
+
+	if !q.IsEmpty() {
+		t.Fatal("expected queue to be empty")
+	}
+}
+
+func Test_DelayDequeueBlocking(t *testing.T) {
+	c := clock.NewFake(epoch)
+	q := queue.NewDelayWithClock[int](c)
+
+	q.EnqueueAfter(1, time.Minute)
+
+	done := make(chan int)
+	go func() {
+		val, err := q.Dequeue(context.Background())
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		done <- val
+	}()
+
+	c.BlockUntil(1)
+
+	q.EnqueueAfter(2, time.Second)
+
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+
+	select {
+	case val := <-done:
+		if val != 2 {
+			t.Fatalf("expected 2, got %d", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dequeue was not released")
+	}
+}
+
+func Test_DelayDequeueRealClock(t *testing.T) {
+	q := queue.NewDelay[int]()
+
+	start := time.Now()
+	q.EnqueueAfter(1, 50*time.Millisecond)
+
+	val, err := q.Dequeue(context.Background())
+	if err != nil || val != 1 {
+		t.Fatalf("expected 1, got %v %v", val, err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("item returned too early: %v", elapsed)
+	}
+}
+
+func Test_DelayDequeueEmptyWakeup(t *testing.T) {
+	q := queue.NewDelay[int]()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		q.EnqueueAfter(7, 0)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if val, err := q.Dequeue(ctx); err != nil || val != 7 {
+		t.Fatalf("expected 7, got %v %v", val, err)
+	}
+}
+
+func Test_DelayDequeueCancel(t *testing.T) {
+	q := queue.NewDelay[int]()
+	q.EnqueueAfter(1, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := q.Dequeue(ctx); !errors.Is(err, queue.ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+
+	q.Clear()
+
+	if !q.IsEmpty() {
+		t.Fatal("expected queue to be empty after Clear")
+	}
+}
+
+func Test_DelayRemove(t *testing.T) {
+	c := clock.NewFake(epoch)
+	q := queue.NewDelayWithClock[string](c)
+
+	a := q.EnqueueAfter("a", time.Second)
+	q.EnqueueAfter("b", 2*time.Second)
+
+	if val, ok := q.Remove(a); !ok || val != "a" {
+		t.Fatalf("expected a, got %v %v", val, ok)
+	}
+
+	if _, ok := q.Remove(a); ok {
+		t.Fatal("expected a removed handle to be rejected")
+	}
+
+	c.Advance(2 * time.Second)
+
+	if val, ok := q.TryDequeue(); !ok || val != "b" {
+		t.Fatalf("expected b, got %v %v", val, ok)
+	}
+
+	if !q.IsEmpty() {
+		t.Fatalf("expected an empty queue, got %d", q.Size())
+	}
+}
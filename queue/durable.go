@@ -0,0 +1,750 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentExt       = ".seg"
+	ackFile          = "ack"
+	recordHeaderSize = 8
+
+	defaultSegmentSize int64 = 64 << 20
+)
+
+var (
+	ErrCorrupted     = errors.New("queue corrupted")
+	ErrUnknownOffset = errors.New("unknown offset")
+)
+
+type Codec[T any] interface {
+	Encode(v T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+type DurableOption func(c *durableConfig)
+
+type durableConfig struct {
+	segmentSize int64
+	syncEvery   int
+}
+
+// WithSyncEvery fsyncs the active segment every n written records, 0 leaves it to the OS.
+func WithSyncEvery(n int) DurableOption {
+	return func(c *durableConfig) {
+		c.syncEvery = max(n, 0)
+	}
+}
+
+func WithSegmentSize(size int64) DurableOption {
+	return func(c *durableConfig) {
+		if size > 0 {
+			c.segmentSize = size
+		}
+	}
+}
+
+// DurableQueue persists every item in append-only segment files, items not acknowledged are delivered again after a restart.
+// Receive returns the Offset of an item so that each consumer acknowledges only what it processed with Ack.
+// AckAll acknowledges every item returned so far by any method, which only suits a single consumer.
+type DurableQueue[T any] interface {
+	Queue[T]
+	Receive(ctx context.Context) (T, Offset, error)
+	Ack(offset Offset) error
+	AckAll() error
+}
+
+// Offset identifies an item returned by Receive.
+type Offset struct {
+	pos position
+}
+
+type position struct {
+	segment uint64
+	offset  int64
+}
+
+func (p position) after(other position) bool {
+	return p.segment > other.segment || (p.segment == other.segment && p.offset > other.offset)
+}
+
+// durableEntry spans from the end of the previous record (start) to the end of its own (end).
+type durableEntry[T any] struct {
+	value T
+	start position
+	end   position
+}
+
+type durableQueue[T any] struct {
+	dir    string
+	codec  Codec[T]
+	config durableConfig
+
+	entries Queue[durableEntry[T]]
+
+	writeMut sync.Mutex
+	active   *os.File
+	written  position
+	unsynced int
+	closed   bool
+	failed   error
+
+	// taken holds the items returned but not acknowledged yet, sorted by start
+	ackMut   sync.Mutex
+	taken    []durableEntry[T]
+	ackedEnd position
+	acked    position
+}
+
+func segmentPath(dir string, segment uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", segment, segmentExt))
+}
+
+func listSegments(dir string) ([]uint64, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []uint64{}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, id)
+	}
+
+	slices.Sort(segments)
+
+	return segments, nil
+}
+
+func readAck(dir string) (position, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ackFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return position{}, nil
+	}
+	if err != nil {
+		return position{}, err
+	}
+
+	if len(data) != 16 {
+		return position{}, ErrCorrupted
+	}
+
+	return position{
+		segment: binary.BigEndian.Uint64(data[:8]),
+		offset:  int64(binary.BigEndian.Uint64(data[8:])),
+	}, nil
+}
+
+func writeAck(dir string, pos position) error {
+	data := make([]byte, 16)
+	binary.BigEndian.PutUint64(data[:8], pos.segment)
+	binary.BigEndian.PutUint64(data[8:], uint64(pos.offset))
+
+	tmp := filepath.Join(dir, ackFile+".tmp")
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(dir, ackFile))
+}
+
+func encodeRecord(payload []byte) []byte {
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeaderSize:], payload)
+	return record
+}
+
+func (q *durableQueue[T]) recoverSegment(segment uint64, from int64) error {
+	f, err := os.OpenFile(segmentPath(q.dir, segment), os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	offset, err := f.Seek(from, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(f)
+	header := make([]byte, recordHeaderSize)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			break
+		}
+
+		payload := make([]byte, binary.BigEndian.Uint32(header[:4]))
+		if _, err := io.ReadFull(r, payload); err != nil {
+			break
+		}
+
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			break
+		}
+
+		value, err := q.codec.Decode(payload)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrCorrupted, err)
+		}
+
+		start := position{segment: segment, offset: offset}
+		offset += int64(recordHeaderSize + len(payload))
+
+		_ = q.entries.Enqueue(durableEntry[T]{
+			value: value,
+			start: start,
+			end:   position{segment: segment, offset: offset},
+		})
+	}
+
+	// Drop the torn tail left by a crash in the middle of a write
+	return f.Truncate(offset)
+}
+
+func (q *durableQueue[T]) load() error {
+	acked, err := readAck(q.dir)
+	if err != nil {
+		return err
+	}
+
+	segments, err := listSegments(q.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if segment < acked.segment {
+			if err := os.Remove(segmentPath(q.dir, segment)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		from := int64(0)
+		if segment == acked.segment {
+			from = acked.offset
+		}
+
+		if err := q.recoverSegment(segment, from); err != nil {
+			return err
+		}
+	}
+
+	q.acked = acked
+	q.ackedEnd = acked
+
+	active := max(acked.segment+1, 1)
+	if len(segments) > 0 && segments[len(segments)-1] >= acked.segment {
+		active = segments[len(segments)-1]
+	}
+
+	return q.openSegment(active)
+}
+
+func (q *durableQueue[T]) openSegment(segment uint64) error {
+	f, err := os.OpenFile(segmentPath(q.dir, segment), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	q.active = f
+	q.written = position{segment: segment, offset: info.Size()}
+
+	return nil
+}
+
+func (q *durableQueue[T]) syncUnsafe(force bool) error {
+	if q.unsynced == 0 || (!force && (q.config.syncEvery == 0 || q.unsynced < q.config.syncEvery)) {
+		return nil
+	}
+
+	q.unsynced = 0
+
+	return q.active.Sync()
+}
+
+func (q *durableQueue[T]) rollbackUnsafe(err error) error {
+	// Drop a partial write so later records keep their positions
+	if terr := q.active.Truncate(q.written.offset); terr != nil {
+		q.failed = errors.Join(err, terr)
+		return q.failed
+	}
+	return err
+}
+
+// writeUnsafe encodes the whole batch first and appends it with a single write,
+// so either every record of the batch is on disk or none is.
+func (q *durableQueue[T]) writeUnsafe(els []T) ([]durableEntry[T], error) {
+	records := [][]byte{}
+	for _, el := range els {
+		payload, err := q.codec.Encode(el)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, encodeRecord(payload))
+	}
+
+	if q.written.offset >= q.config.segmentSize {
+		if err := q.syncUnsafe(q.config.syncEvery > 0); err != nil {
+			return nil, err
+		}
+		if err := q.active.Close(); err != nil {
+			return nil, err
+		}
+		if err := q.openSegment(q.written.segment + 1); err != nil {
+			q.failed = err
+			return nil, err
+		}
+	}
+
+	if _, err := q.active.Write(slices.Concat(records...)); err != nil {
+		return nil, q.rollbackUnsafe(err)
+	}
+
+	q.unsynced += len(records)
+
+	if err := q.syncUnsafe(false); err != nil {
+		return nil, q.rollbackUnsafe(err)
+	}
+
+	entries := make([]durableEntry[T], 0, len(els))
+	for i, el := range els {
+		start := q.written
+		q.written.offset += int64(len(records[i]))
+		entries = append(entries, durableEntry[T]{value: el, start: start, end: q.written})
+	}
+
+	return entries, nil
+}
+
+func (q *durableQueue[T]) Enqueue(el T) error {
+	return q.EnqueueAll(el)
+}
+
+func (q *durableQueue[T]) EnqueueAll(els ...T) error {
+	q.writeMut.Lock()
+	defer q.writeMut.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	if q.failed != nil {
+		return q.failed
+	}
+
+	if len(els) == 0 {
+		return nil
+	}
+
+	entries, err := q.writeUnsafe(els)
+	if err != nil {
+		return err
+	}
+
+	return q.entries.EnqueueAll(entries...)
+}
+
+func compareStart[T any](a durableEntry[T], b position) int {
+	switch {
+	case a.start.after(b):
+		return 1
+	case b.after(a.start):
+		return -1
+	default:
+		return 0
+	}
+}
+
+func (q *durableQueue[T]) takeUnsafe(entry durableEntry[T]) {
+	i, _ := slices.BinarySearchFunc(q.taken, entry.start, compareStart[T])
+	q.taken = slices.Insert(q.taken, i, entry)
+}
+
+// Items are taken from entries and registered in taken under the same lock,
+// so the acknowledged position never skips an item in the hands of a consumer.
+func (q *durableQueue[T]) tryTake() (durableEntry[T], bool) {
+	q.ackMut.Lock()
+	defer q.ackMut.Unlock()
+
+	entry, ok := q.entries.TryDequeue()
+	if ok {
+		q.takeUnsafe(entry)
+	}
+
+	return entry, ok
+}
+
+func (q *durableQueue[T]) take(ctx context.Context) (durableEntry[T], error) {
+	for {
+		if entry, ok := q.tryTake(); ok {
+			return entry, nil
+		}
+
+		if _, err := q.entries.Peek(ctx); err != nil {
+			return durableEntry[T]{}, err
+		}
+	}
+}
+
+// untake puts back at the head an item that never reached its consumer.
+func (q *durableQueue[T]) untake(entry durableEntry[T]) {
+	q.ackMut.Lock()
+	defer q.ackMut.Unlock()
+
+	if i, ok := slices.BinarySearchFunc(q.taken, entry.start, compareStart[T]); ok {
+		q.taken = slices.Delete(q.taken, i, i+1)
+	}
+
+	q.entries.(requeuer[durableEntry[T]]).requeue(entry)
+}
+
+func (q *durableQueue[T]) TryDequeue() (T, bool) {
+	entry, ok := q.tryTake()
+	return entry.value, ok
+}
+
+func (q *durableQueue[T]) Dequeue(ctx context.Context) (T, error) {
+	if ctx.Err() != nil {
+		var zero T
+		return zero, contextError(ctx)
+	}
+
+	entry, err := q.take(ctx)
+	return entry.value, err
+}
+
+func (q *durableQueue[T]) Receive(ctx context.Context) (T, Offset, error) {
+	if ctx.Err() != nil {
+		var zero T
+		return zero, Offset{}, contextError(ctx)
+	}
+
+	entry, err := q.take(ctx)
+	if err != nil {
+		return entry.value, Offset{}, err
+	}
+
+	return entry.value, Offset{pos: entry.start}, nil
+}
+
+func (q *durableQueue[T]) DequeueN(ctx context.Context, limit int) ([]T, error) {
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	entry, err := q.take(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []T{entry.value}
+	for len(values) < limit {
+		entry, ok := q.tryTake()
+		if !ok {
+			break
+		}
+		values = append(values, entry.value)
+	}
+
+	return values, nil
+}
+
+func (q *durableQueue[T]) DequeueBatch(ctx context.Context, limit int, linger time.Duration) ([]T, error) {
+	return dequeueBatch(ctx, q, limit, linger)
+}
+
+func (q *durableQueue[T]) TryPeek() (T, bool) {
+	entry, ok := q.entries.TryPeek()
+	return entry.value, ok
+}
+
+func (q *durableQueue[T]) Peek(ctx context.Context) (T, error) {
+	entry, err := q.entries.Peek(ctx)
+	return entry.value, err
+}
+
+func (q *durableQueue[T]) Drain() []T {
+	q.ackMut.Lock()
+	defer q.ackMut.Unlock()
+
+	entries := q.entries.Drain()
+
+	values := make([]T, 0, len(entries))
+	for _, entry := range entries {
+		q.takeUnsafe(entry)
+		values = append(values, entry.value)
+	}
+
+	return values
+}
+
+func (q *durableQueue[T]) Snapshot() []T {
+	entries := q.entries.Snapshot()
+
+	values := make([]T, 0, len(entries))
+	for _, entry := range entries {
+		values = append(values, entry.value)
+	}
+
+	return values
+}
+
+func (q *durableQueue[T]) All(ctx context.Context) iter.Seq[T] {
+	return all[T](ctx, q)
+}
+
+// toChannel is used by ToChannel, the channel is unbuffered so an item is only
+// taken off the queue once a reader is there, or put back at the head.
+func (q *durableQueue[T]) toChannel(ctx context.Context) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		for {
+			entry, err := q.take(ctx)
+			if err != nil {
+				return
+			}
+
+			select {
+			case out <- entry.value:
+			case <-ctx.Done():
+				q.untake(entry)
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+func (q *durableQueue[T]) ackUnsafe(pos position) error {
+	if !pos.after(q.acked) {
+		return nil
+	}
+
+	if err := writeAck(q.dir, pos); err != nil {
+		return err
+	}
+
+	segments, err := listSegments(q.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if segment >= pos.segment {
+			break
+		}
+		if err := os.Remove(segmentPath(q.dir, segment)); err != nil {
+			return err
+		}
+	}
+
+	q.acked = pos
+
+	return nil
+}
+
+// commitUnsafe persists the highest position below which every item is acknowledged.
+func (q *durableQueue[T]) commitUnsafe() error {
+	pos := q.ackedEnd
+
+	if len(q.taken) > 0 && pos.after(q.taken[0].start) {
+		pos = q.taken[0].start
+	}
+
+	if head, ok := q.entries.TryPeek(); ok && pos.after(head.start) {
+		pos = head.start
+	}
+
+	return q.ackUnsafe(pos)
+}
+
+func (q *durableQueue[T]) Ack(offset Offset) error {
+	q.ackMut.Lock()
+	defer q.ackMut.Unlock()
+
+	i, ok := slices.BinarySearchFunc(q.taken, offset.pos, compareStart[T])
+	if !ok {
+		return ErrUnknownOffset
+	}
+
+	if end := q.taken[i].end; end.after(q.ackedEnd) {
+		q.ackedEnd = end
+	}
+	q.taken = slices.Delete(q.taken, i, i+1)
+
+	return q.commitUnsafe()
+}
+
+func (q *durableQueue[T]) AckAll() error {
+	q.ackMut.Lock()
+	defer q.ackMut.Unlock()
+
+	for _, entry := range q.taken {
+		if entry.end.after(q.ackedEnd) {
+			q.ackedEnd = entry.end
+		}
+	}
+	q.taken = nil
+
+	return q.commitUnsafe()
+}
+
+func (q *durableQueue[T]) Close() {
+	q.writeMut.Lock()
+	defer q.writeMut.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	q.closed = true
+	q.entries.Close()
+
+	_ = q.syncUnsafe(true)
+	_ = q.active.Close()
+}
+
+func (q *durableQueue[T]) Clear() {
+	q.writeMut.Lock()
+	defer q.writeMut.Unlock()
+
+	q.ackMut.Lock()
+	defer q.ackMut.Unlock()
+
+	q.entries.Clear()
+
+	// Items already taken by a consumer still wait for their own acknowledgement
+	q.ackedEnd = q.written
+	_ = q.commitUnsafe()
+}
+
+func (q *durableQueue[T]) Size() int {
+	return q.entries.Size()
+}
+
+func (q *durableQueue[T]) IsEmpty() bool {
+	return q.entries.IsEmpty()
+}
+
+// NewDurable opens (or creates) a durable queue in dir, a nil codec defaults to gob.
+func NewDurable[T any](dir string, codec Codec[T], opts ...DurableOption) (DurableQueue[T], error) {
+	if codec == nil {
+		codec = GobCodec[T]{}
+	}
+
+	config := durableConfig{
+		segmentSize: defaultSegmentSize,
+		syncEvery:   1,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	q := &durableQueue[T]{
+		dir:     dir,
+		codec:   codec,
+		config:  config,
+		entries: New[durableEntry[T]](WithRingBuffer(0)),
+	}
+
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
@@ -0,0 +1,379 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/queue"
+)
+
+type event struct {
+	ID   int
+	Name string
+}
+
+func segments(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func Test_DurableEnqueueDequeue(t *testing.T) {
+	q, err := queue.NewDurable[event](t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if err := q.EnqueueAll(event{1, "a"}, event{2, "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(event{3, "c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if size := q.Size(); size != 3 {
+		t.Fatalf("expected size 3, got %d", size)
+	}
+
+	for i := 1; i <= 3; i++ {
+		val, err := q.Dequeue(context.Background())
+		if err != nil || val.ID != i {
+			t.Fatalf("expected %d, got %v %v", i, val, err)
+		}
+	}
+
+	if !q.IsEmpty() {
+		t.Fatal("expected queue to be empty")
+	}
+}
+
+func Test_DurableRecovery(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := queue.NewDurable(dir, queue.JSONCodec[event]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 5 {
+		if err := q.Enqueue(event{ID: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Items 0 and 1 are acknowledged, item 2 is delivered but not acknowledged
+	_, _ = q.TryDequeue()
+	_, _ = q.TryDequeue()
+	if err := q.AckAll(); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = q.TryDequeue()
+
+	q.Close()
+
+	q, err = queue.NewDurable(dir, queue.JSONCodec[event]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if size := q.Size(); size != 3 {
+		t.Fatalf("expected 3 unacknowledged items, got %d", size)
+	}
+
+	for i := 2; i < 5; i++ {
+		val, ok := q.TryDequeue()
+		if !ok || val.ID != i {
+			t.Fatalf("expected %d, got %v %v", i, val, ok)
+		}
+	}
+
+	if err := q.Enqueue(event{ID: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	if val, ok := q.TryDequeue(); !ok || val.ID != 5 {
+		t.Fatalf("expected 5, got %v %v", val, ok)
+	}
+}
+
+func Test_DurableCompaction(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := queue.NewDurable[int](dir, nil, queue.WithSegmentSize(64), queue.WithSyncEvery(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	for i := range 50 {
+		if err := q.Enqueue(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before := len(segments(t, dir))
+	if before < 2 {
+		t.Fatalf("expected several segments, got %d", before)
+	}
+
+	if _, err := q.DequeueN(context.Background(), 50); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.AckAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if after := len(segments(t, dir)); after != 1 {
+		t.Fatalf("expected only the active segment after compaction, got %d", after)
+	}
+}
+
+func Test_DurableTornWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := queue.NewDurable[string](dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enqueue(t, q, "a", "b")
+	q.Close()
+
+	files := segments(t, dir)
+	f, err := os.OpenFile(files[len(files)-1], os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{0, 0, 0, 42, 1, 2})
+	_ = f.Close()
+
+	q, err = queue.NewDurable[string](dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if size := q.Size(); size != 2 {
+		t.Fatalf("expected 2 items after recovery, got %d", size)
+	}
+
+	enqueue(t, q, "c")
+
+	items, err := q.DequeueN(context.Background(), 3)
+	if err != nil || len(items) != 3 || items[2] != "c" {
+		t.Fatalf("expected [a b c], got %v %v", items, err)
+	}
+}
+
+func Test_DurableClearAndClose(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := queue.NewDurable[int](dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enqueue(t, q, 1, 2, 3)
+	q.Clear()
+
+	if !q.IsEmpty() {
+		t.Fatal("expected queue to be empty after Clear")
+	}
+
+	q.Close()
+
+	if err := q.Enqueue(4); err != queue.ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+
+	q, err = queue.NewDurable[int](dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if !q.IsEmpty() {
+		t.Fatalf("expected cleared items to stay removed, got %d", q.Size())
+	}
+}
+
+func Test_DurablePeekDrainSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := queue.NewDurable[int](dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enqueue(t, q, 1, 2, 3)
+
+	if val, ok := q.TryPeek(); !ok || val != 1 {
+		t.Fatalf("expected 1, got %v %v", val, ok)
+	}
+
+	if snapshot := q.Snapshot(); len(snapshot) != 3 || snapshot[2] != 3 {
+		t.Fatalf("expected [1 2 3], got %v", snapshot)
+	}
+
+	if drained := q.Drain(); len(drained) != 3 {
+		t.Fatalf("expected 3 drained items, got %v", drained)
+	}
+
+	if err := q.AckAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	q.Close()
+
+	q, err = queue.NewDurable[int](dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if !q.IsEmpty() {
+		t.Fatalf("expected drained and acknowledged items to stay removed, got %d", q.Size())
+	}
+}
+
+func Test_DurableFailedBatch(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := queue.NewDurable(dir, queue.JSONCodec[any]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.EnqueueAll("a", func() {}); err == nil {
+		t.Fatal("expected an encoding error")
+	}
+
+	if size := q.Size(); size != 0 {
+		t.Fatalf("expected an empty queue, got %d", size)
+	}
+
+	if err := q.Enqueue("b"); err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+
+	q, err = queue.NewDurable(dir, queue.JSONCodec[any]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if items := q.Snapshot(); len(items) != 1 || items[0] != "b" {
+		t.Fatalf("expected [b] after restart, got %v", items)
+	}
+}
+
+func Test_DurableAckPerItem(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := queue.NewDurable[int](dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.EnqueueAll(1, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// Consumer A takes 1, consumer B takes 2 and acknowledges it
+	if _, _, err := q.Receive(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	val, second, err := q.Receive(context.Background())
+	if err != nil || val != 2 {
+		t.Fatalf("expected 2, got %v %v", val, err)
+	}
+
+	if err := q.Ack(second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Ack(second); !errors.Is(err, queue.ErrUnknownOffset) {
+		t.Fatalf("expected ErrUnknownOffset, got %v", err)
+	}
+
+	q.Close()
+
+	q, err = queue.NewDurable[int](dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Item 1 was never acknowledged, so it is delivered again
+	if items := q.Snapshot(); !slices.Equal(items, []int{1, 2, 3}) {
+		t.Fatalf("expected [1 2 3] after restart, got %v", items)
+	}
+
+	_, first, _ := q.Receive(context.Background())
+	_, second, _ = q.Receive(context.Background())
+
+	if err := q.Ack(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Ack(first); err != nil {
+		t.Fatal(err)
+	}
+
+	q.Close()
+
+	q, err = queue.NewDurable[int](dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if items := q.Snapshot(); !slices.Equal(items, []int{3}) {
+		t.Fatalf("expected [3] after restart, got %v", items)
+	}
+}
+
+func Test_DurableToChannelCancel(t *testing.T) {
+	q, err := queue.NewDurable[int](t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if err := q.EnqueueAll(1, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch := queue.ToChannel(ctx, q, 8)
+
+	if val := <-ch; val != 1 {
+		t.Fatalf("expected 1, got %d", val)
+	}
+
+	// Stop reading while the pump holds item 2
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	for range ch {
+	}
+
+	if items := q.Snapshot(); !slices.Equal(items, []int{2, 3}) {
+		t.Fatalf("expected [2 3] back at the head, got %v", items)
+	}
+
+	if err := q.AckAll(); err != nil {
+		t.Fatal(err)
+	}
+}
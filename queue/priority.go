@@ -0,0 +1,244 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+type Handle[T any] struct {
+	value T
+	seq   uint64
+	index int
+	owner any
+}
+
+type priorityHeap[T any] struct {
+	less  func(a, b T) bool
+	items []*Handle[T]
+	seq   uint64
+}
+
+func (h *priorityHeap[T]) Len() int {
+	return len(h.items)
+}
+
+func (h *priorityHeap[T]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+
+	if h.less(a.value, b.value) {
+		return true
+	}
+	if h.less(b.value, a.value) {
+		return false
+	}
+
+	return a.seq < b.seq
+}
+
+func (h *priorityHeap[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+func (h *priorityHeap[T]) Push(x any) {
+	item := x.(*Handle[T])
+	item.index = len(h.items)
+	h.items = append(h.items, item)
+}
+
+func (h *priorityHeap[T]) Pop() any {
+	n := len(h.items)
+	item := h.items[n-1]
+	h.items[n-1] = nil
+	h.items = h.items[:n-1]
+	item.index = -1
+	return item
+}
+
+func (h *priorityHeap[T]) push(value T, owner any) *Handle[T] {
+	h.seq++
+
+	item := &Handle[T]{value: value, seq: h.seq, owner: owner}
+	heap.Push(h, item)
+
+	return item
+}
+
+func (h *priorityHeap[T]) peek() (*Handle[T], bool) {
+	if len(h.items) == 0 {
+		return nil, false
+	}
+	return h.items[0], true
+}
+
+func (h *priorityHeap[T]) pop() (*Handle[T], bool) {
+	if len(h.items) == 0 {
+		return nil, false
+	}
+	return heap.Pop(h).(*Handle[T]), true
+}
+
+func (h *priorityHeap[T]) contains(item *Handle[T], owner any) bool {
+	return item != nil && item.owner == owner && item.index >= 0 && item.index < len(h.items) && h.items[item.index] == item
+}
+
+func (h *priorityHeap[T]) clear() {
+	for _, item := range h.items {
+		item.index = -1
+	}
+	h.items = nil
+}
+
+type PriorityQueue[T any] interface {
+	Enqueue(el T) *Handle[T]
+	TryDequeue() (T, bool)
+	Dequeue(ctx context.Context) (T, error)
+	TryPeek() (T, bool)
+	Update(h *Handle[T], el T) bool
+	Remove(h *Handle[T]) (T, bool)
+	Clear()
+	IsEmpty() bool
+	Size() int
+}
+
+type heapQueue[T any] struct {
+	mut  *sync.Mutex
+	cond *sync.Cond
+
+	h *priorityHeap[T]
+}
+
+func (q *heapQueue[T]) Enqueue(el T) *Handle[T] {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	item := q.h.push(el, q)
+
+	q.cond.Signal()
+
+	return item
+}
+
+func (q *heapQueue[T]) dequeueUnsafe() (T, bool) {
+	item, ok := q.h.pop()
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return item.value, true
+}
+
+func (q *heapQueue[T]) TryDequeue() (T, bool) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	return q.dequeueUnsafe()
+}
+
+func (q *heapQueue[T]) Dequeue(ctx context.Context) (T, error) {
+	if ctx.Err() != nil {
+		var zero T
+		return zero, contextError(ctx)
+	}
+
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			q.mut.Lock()
+			q.cond.Broadcast()
+			q.mut.Unlock()
+		case <-stop:
+			return
+		}
+	}()
+
+	for {
+		if item, ok := q.dequeueUnsafe(); ok {
+			return item, nil
+		}
+
+		if ctx.Err() != nil {
+			var zero T
+			return zero, contextError(ctx)
+		}
+
+		q.cond.Wait()
+	}
+}
+
+func (q *heapQueue[T]) TryPeek() (T, bool) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	item, ok := q.h.peek()
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return item.value, true
+}
+
+func (q *heapQueue[T]) Update(h *Handle[T], el T) bool {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if !q.h.contains(h, q) {
+		return false
+	}
+
+	h.value = el
+	heap.Fix(q.h, h.index)
+
+	return true
+}
+
+func (q *heapQueue[T]) Remove(h *Handle[T]) (T, bool) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if !q.h.contains(h, q) {
+		var zero T
+		return zero, false
+	}
+
+	heap.Remove(q.h, h.index)
+
+	return h.value, true
+}
+
+func (q *heapQueue[T]) Clear() {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	q.h.clear()
+
+	q.cond.Broadcast()
+}
+
+func (q *heapQueue[T]) Size() int {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	return q.h.Len()
+}
+
+func (q *heapQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+func NewPriority[T any](less func(a, b T) bool) PriorityQueue[T] {
+	var mut sync.Mutex
+	return &heapQueue[T]{
+		mut:  &mut,
+		cond: sync.NewCond(&mut),
+		h:    &priorityHeap[T]{less: less},
+	}
+}
@@ -0,0 +1,170 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/queue"
+)
+
+type job struct {
+	name     string
+	priority int
+}
+
+func byPriority(a, b job) bool {
+	return a.priority < b.priority
+}
+
+func Test_PriorityOrder(t *testing.T) {
+	q := queue.NewPriority(func(a, b int) bool { return a < b })
+
+	for _, v := range []int{5, 1, 4, 2, 3} {
+		q.Enqueue(v)
+	}
+
+	if q.Size() != 5 {
+		t.Fatalf("expected size 5, got %d", q.Size())
+	}
+
+	if val, ok := q.TryPeek(); !ok || val != 1 {
+		t.Fatalf("expected peek 1, got %v %v", val, ok)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if val, err := q.Dequeue(context.Background()); err != nil || val != i {
+			t.Fatalf("expected %d, got %v %v", i, val, err)
+		}
+	}
+
+	if !q.IsEmpty() {
+		t.Fatal("expected queue to be empty")
+	}
+}
+
+func Test_PriorityStable(t *testing.T) {
+	q := queue.NewPriority(byPriority)
+
+	q.Enqueue(job{"a", 1})
+	q.Enqueue(job{"b", 0})
+	q.Enqueue(job{"c", 1})
+	q.Enqueue(job{"d", 0})
+	q.Enqueue(job{"e", 1})
+
+	expected := []string{"b", "d", "a", "c", "e"}
+	for _, name := range expected {
+		val, ok := q.TryDequeue()
+		if !ok || val.name != name {
+			t.Fatalf("expected %s, got %v %v", name, val, ok)
+		}
+	}
+}
+
+func Test_PriorityUpdateRemove(t *testing.T) {
+	q := queue.NewPriority(byPriority)
+
+	a := q.Enqueue(job{"a", 1})
+	b := q.Enqueue(job{"b", 2})
+	c := q.Enqueue(job{"c", 3})
+
+	if !q.Update(c, job{"c", 0}) {
+		t.Fatal("expected Update to succeed")
+	}
+
+	if val, ok := q.Remove(b); !ok || val.name != "b" {
+		t.Fatalf("expected to remove b, got %v %v", val, ok)
+	}
+
+	if _, ok := q.Remove(b); ok {
+		t.Fatal("expected second Remove to fail")
+	}
+
+	if val, _ := q.TryDequeue(); val.name != "c" {
+		t.Fatalf("expected c, got %v", val)
+	}
+
+	if val, _ := q.TryDequeue(); val.name != "a" {
+		t.Fatalf("expected a, got %v", val)
+	}
+
+	if q.Update(a, job{"a", 5}) {
+		t.Fatal("expected Update of a dequeued item to fail")
+	}
+
+	other := queue.NewPriority(byPriority)
+	h := other.Enqueue(job{"x", 1})
+	if q.Update(h, job{"x", 0}) {
+		t.Fatal("expected Update with a foreign handle to fail")
+	}
+}
+
+func Test_PriorityDequeueBlocking(t *testing.T) {
+	q := queue.NewPriority(func(a, b int) bool { return a < b })
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		q.Enqueue(42)
+	}()
+
+	if val, err := q.Dequeue(context.Background()); err != nil || val != 42 {
+		t.Fatalf("expected 42, got %v %v", val, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := q.Dequeue(ctx); !errors.Is(err, queue.ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+}
+
+func Test_PriorityClear(t *testing.T) {
+	q := queue.NewPriority(func(a, b int) bool { return a < b })
+
+	h := q.Enqueue(1)
+	q.Enqueue(2)
+	q.Clear()
+
+	if !q.IsEmpty() {
+		t.Fatal("expected queue to be empty after Clear")
+	}
+
+	if _, ok := q.Remove(h); ok {
+		t.Fatal("expected Remove after Clear to fail")
+	}
+}
+
+func Test_PriorityConcurrent(t *testing.T) {
+	q := queue.NewPriority(func(a, b int) bool { return a < b })
+	const n = 100
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := range n {
+			q.Enqueue(i)
+		}
+	}()
+
+	received := 0
+	go func() {
+		defer wg.Done()
+		for range n {
+			if _, err := q.Dequeue(context.Background()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			received++
+		}
+	}()
+
+	wg.Wait()
+
+	if received != n || !q.IsEmpty() {
+		t.Fatalf("expected %d items and an empty queue, got %d", n, received)
+	}
+}
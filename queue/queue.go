@@ -3,99 +3,275 @@ package queue
 import (
 	"context"
 	"errors"
+	"iter"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 var (
 	ErrTimeout  = errors.New("context timeout")
 	ErrCanceled = errors.New("context canceled")
+	ErrClosed   = errors.New("queue closed")
 )
 
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return ErrCanceled
+}
+
 type Queue[T any] interface {
-	Enqueue(el T)
+	Enqueue(el T) error
+	EnqueueAll(els ...T) error
 	TryDequeue() (T, bool)
 	Dequeue(ctx context.Context) (T, error)
+	DequeueN(ctx context.Context, limit int) ([]T, error)
+	DequeueBatch(ctx context.Context, limit int, linger time.Duration) ([]T, error)
+	TryPeek() (T, bool)
+	Peek(ctx context.Context) (T, error)
+	Drain() []T
+	Snapshot() []T
+	All(ctx context.Context) iter.Seq[T]
+	Close()
 	Clear()
 	IsEmpty() bool
 	Size() int
 }
 
+type storage[T any] interface {
+	push(el T)
+	pushFront(el T)
+	pop() (T, bool)
+	peek() (T, bool)
+	values() []T
+	len() int
+	clear()
+}
+
 type node[T any] struct {
 	value T
 	next  *node[T]
 }
 
-type linkedListQueue[T any] struct {
-	mut  *sync.Mutex
-	cond *sync.Cond
-
+type linkedList[T any] struct {
 	head *node[T]
 	tail *node[T]
-
-	size int64
+	size int
 }
 
-func (q *linkedListQueue[T]) Enqueue(el T) {
-	q.mut.Lock()
-	defer q.mut.Unlock()
-
+func (l *linkedList[T]) push(el T) {
 	newVal := &node[T]{value: el}
 
-	if q.tail == nil {
-		q.head = newVal
-		q.tail = newVal
+	if l.tail == nil {
+		l.head = newVal
+		l.tail = newVal
 	} else {
-		q.tail.next = newVal
-		q.tail = newVal
+		l.tail.next = newVal
+		l.tail = newVal
 	}
 
-	atomic.AddInt64(&q.size, 1)
+	l.size++
+}
+
+func (l *linkedList[T]) pushFront(el T) {
+	newVal := &node[T]{value: el, next: l.head}
+
+	l.head = newVal
+	if l.tail == nil {
+		l.tail = newVal
+	}
 
-	q.cond.Signal()
+	l.size++
 }
 
-func (q *linkedListQueue[T]) dequeueUnsafe() (T, bool) {
-	if q.size == 0 {
+func (l *linkedList[T]) pop() (T, bool) {
+	if l.head == nil {
 		var zero T
 		return zero, false
 	}
 
-	el := q.head
+	el := l.head
 
 	if el.next != nil {
-		q.head = el.next
+		l.head = el.next
 	} else {
-		q.head = nil
-		q.tail = nil
+		l.head = nil
+		l.tail = nil
 	}
 
 	el.next = nil
-
-	atomic.AddInt64(&q.size, -1)
+	l.size--
 
 	return el.value, true
 }
 
-func (q *linkedListQueue[T]) TryDequeue() (T, bool) {
+func (l *linkedList[T]) peek() (T, bool) {
+	if l.head == nil {
+		var zero T
+		return zero, false
+	}
+	return l.head.value, true
+}
+
+func (l *linkedList[T]) values() []T {
+	values := make([]T, 0, l.size)
+	for n := l.head; n != nil; n = n.next {
+		values = append(values, n.value)
+	}
+	return values
+}
+
+func (l *linkedList[T]) len() int {
+	return l.size
+}
+
+func (l *linkedList[T]) clear() {
+	l.head = nil
+	l.tail = nil
+	l.size = 0
+}
+
+type Option func(c *config)
+
+type config struct {
+	ring            bool
+	initialCapacity int
+	fair            bool
+}
+
+func WithLinkedList() Option {
+	return func(c *config) {
+		c.ring = false
+	}
+}
+
+// WithRingBuffer stores items in a growable circular buffer preallocated with initialCapacity slots.
+// The buffer is neither bounded (see NewBounded) nor lock-free, it shares the queue mutex like the linked list.
+func WithRingBuffer(initialCapacity int) Option {
+	return func(c *config) {
+		c.ring = true
+		c.initialCapacity = initialCapacity
+	}
+}
+
+// WithFairness serves blocked Dequeue callers in arrival order, handing items over directly.
+func WithFairness() Option {
+	return func(c *config) {
+		c.fair = true
+	}
+}
+
+type myQueue[T any] struct {
+	mut  *sync.Mutex
+	cond *sync.Cond
+
+	items  storage[T]
+	closed bool
+
+	peekers int
+
+	fair    bool
+	waiters []chan T
+	done    chan struct{}
+
+	size int64
+}
+
+func (q *myQueue[T]) Enqueue(el T) error {
 	q.mut.Lock()
 	defer q.mut.Unlock()
 
-	return q.dequeueUnsafe()
+	if q.closed {
+		return ErrClosed
+	}
+
+	q.enqueueUnsafe(el)
+
+	if q.peekers > 0 {
+		q.cond.Broadcast()
+	} else {
+		q.cond.Signal()
+	}
+
+	return nil
 }
 
-func (q *linkedListQueue[T]) Dequeue(ctx context.Context) (T, error) {
-	if ctx.Err() != nil {
-		var zero T
-		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return zero, ErrTimeout
+func (q *myQueue[T]) EnqueueAll(els ...T) error {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	for _, el := range els {
+		q.enqueueUnsafe(el)
+	}
+
+	q.cond.Broadcast()
+
+	return nil
+}
+
+func (q *myQueue[T]) enqueueUnsafe(el T) {
+	if len(q.waiters) > 0 {
+		waiter := q.waiters[0]
+		q.waiters = q.waiters[1:]
+		waiter <- el
+		return
+	}
+
+	q.items.push(el)
+	atomic.AddInt64(&q.size, 1)
+}
+
+// requeue puts items taken out of the queue back at its head, keeping their order.
+// Unlike Enqueue it works on a closed queue, so the items can still be drained.
+func (q *myQueue[T]) requeue(els ...T) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if len(q.waiters) > 0 {
+		// Waiters only exist while the storage is empty
+		for _, el := range els {
+			q.enqueueUnsafe(el)
+		}
+	} else {
+		for i := len(els) - 1; i >= 0; i-- {
+			q.items.pushFront(els[i])
 		}
-		return zero, ErrCanceled
+		atomic.AddInt64(&q.size, int64(len(els)))
 	}
 
+	q.cond.Broadcast()
+}
+
+func (q *myQueue[T]) dequeueUnsafe() (T, bool) {
+	el, ok := q.items.pop()
+	if ok {
+		atomic.AddInt64(&q.size, -1)
+	}
+	return el, ok
+}
+
+func (q *myQueue[T]) TryDequeue() (T, bool) {
 	q.mut.Lock()
 	defer q.mut.Unlock()
 
+	return q.dequeueUnsafe()
+}
+
+func (q *myQueue[T]) waitUnsafe(ctx context.Context) error {
+	if q.size > 0 {
+		return nil
+	}
+
+	if q.closed {
+		return ErrClosed
+	}
+
 	stop := make(chan struct{})
 	defer close(stop)
 
@@ -110,46 +286,274 @@ func (q *linkedListQueue[T]) Dequeue(ctx context.Context) (T, error) {
 		}
 	}()
 
-	for {
-		if item, ok := q.dequeueUnsafe(); ok {
-			return item, nil
+	for q.size == 0 {
+		if q.closed {
+			return ErrClosed
 		}
 
-		if err := ctx.Err(); err != nil {
-			var zero T
-			if errors.Is(err, context.DeadlineExceeded) {
-				return zero, ErrTimeout
-			}
-			return zero, ErrCanceled
+		if ctx.Err() != nil {
+			return contextError(ctx)
 		}
 
 		q.cond.Wait()
 	}
+
+	return nil
+}
+
+func (q *myQueue[T]) removeWaiterUnsafe(waiter chan T) bool {
+	for i, w := range q.waiters {
+		if w == waiter {
+			q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (q *myQueue[T]) dequeueFair(ctx context.Context) (T, error) {
+	var zero T
+
+	q.mut.Lock()
+
+	if item, ok := q.dequeueUnsafe(); ok {
+		q.mut.Unlock()
+		return item, nil
+	}
+
+	if q.closed {
+		q.mut.Unlock()
+		return zero, ErrClosed
+	}
+
+	waiter := make(chan T, 1)
+	q.waiters = append(q.waiters, waiter)
+
+	q.mut.Unlock()
+
+	select {
+	case item := <-waiter:
+		return item, nil
+	case <-ctx.Done():
+	case <-q.done:
+	}
+
+	q.mut.Lock()
+	removed := q.removeWaiterUnsafe(waiter)
+	q.mut.Unlock()
+
+	// The item was handed over while giving up, keep it
+	if !removed {
+		return <-waiter, nil
+	}
+
+	if ctx.Err() != nil {
+		return zero, contextError(ctx)
+	}
+
+	return zero, ErrClosed
 }
 
-func (q *linkedListQueue[T]) Clear() {
+func (q *myQueue[T]) Dequeue(ctx context.Context) (T, error) {
+	if ctx.Err() != nil {
+		var zero T
+		return zero, contextError(ctx)
+	}
+
+	if q.fair {
+		return q.dequeueFair(ctx)
+	}
+
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if err := q.waitUnsafe(ctx); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	item, _ := q.dequeueUnsafe()
+	return item, nil
+}
+
+func (q *myQueue[T]) DequeueN(ctx context.Context, limit int) ([]T, error) {
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	items := []T{}
+
+	if q.fair {
+		item, err := q.dequeueFair(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if !q.fair {
+		if err := q.waitUnsafe(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	for len(items) < limit {
+		item, ok := q.dequeueUnsafe()
+		if !ok {
+			break
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (q *myQueue[T]) DequeueBatch(ctx context.Context, limit int, linger time.Duration) ([]T, error) {
+	return dequeueBatch(ctx, q, limit, linger)
+}
+
+func (q *myQueue[T]) TryPeek() (T, bool) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	return q.items.peek()
+}
+
+func (q *myQueue[T]) Peek(ctx context.Context) (T, error) {
+	if ctx.Err() != nil {
+		var zero T
+		return zero, contextError(ctx)
+	}
+
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	q.peekers++
+	defer func() { q.peekers-- }()
+
+	if err := q.waitUnsafe(ctx); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	item, _ := q.items.peek()
+	return item, nil
+}
+
+func (q *myQueue[T]) Drain() []T {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	items := q.items.values()
+
+	atomic.StoreInt64(&q.size, 0)
+	q.items.clear()
+
+	return items
+}
+
+func (q *myQueue[T]) Snapshot() []T {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	return q.items.values()
+}
+
+func (q *myQueue[T]) All(ctx context.Context) iter.Seq[T] {
+	return all[T](ctx, q)
+}
+
+func (q *myQueue[T]) Close() {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	q.closed = true
+	close(q.done)
+
+	q.cond.Broadcast()
+}
+
+func (q *myQueue[T]) Clear() {
 	q.mut.Lock()
 	defer q.mut.Unlock()
 
 	atomic.StoreInt64(&q.size, 0)
-	q.head = nil
-	q.tail = nil
+	q.items.clear()
 
 	q.cond.Broadcast()
 }
 
-func (q *linkedListQueue[T]) Size() int {
+func (q *myQueue[T]) Size() int {
 	return int(atomic.LoadInt64(&q.size))
 }
 
-func (q *linkedListQueue[T]) IsEmpty() bool {
+func (q *myQueue[T]) IsEmpty() bool {
 	return atomic.LoadInt64(&q.size) == 0
 }
 
-func New[T any]() Queue[T] {
+func all[T any](ctx context.Context, q Queue[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			item, err := q.Dequeue(ctx)
+			if err != nil {
+				return
+			}
+
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+func dequeueBatch[T any](ctx context.Context, q Queue[T], limit int, linger time.Duration) ([]T, error) {
+	batch, err := q.DequeueN(ctx, limit)
+	if err != nil || len(batch) >= limit || linger <= 0 {
+		return batch, err
+	}
+
+	lingerCtx, cancel := context.WithTimeout(ctx, linger)
+	defer cancel()
+
+	for len(batch) < limit {
+		items, err := q.DequeueN(lingerCtx, limit-len(batch))
+		if err != nil {
+			break
+		}
+		batch = append(batch, items...)
+	}
+
+	return batch, nil
+}
+
+func New[T any](opts ...Option) Queue[T] {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	var items storage[T] = &linkedList[T]{}
+	if c.ring {
+		items = newRing[T](c.initialCapacity)
+	}
+
 	var mut sync.Mutex
-	return &linkedListQueue[T]{
-		mut:  &mut,
-		cond: sync.NewCond(&mut),
+	return &myQueue[T]{
+		mut:   &mut,
+		cond:  sync.NewCond(&mut),
+		items: items,
+		fair:  c.fair,
+		done:  make(chan struct{}),
 	}
 }
@@ -4,15 +4,24 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/provincialig/golimitless/queue"
 )
 
+func enqueue[T any](tb testing.TB, q queue.Queue[T], els ...T) {
+	tb.Helper()
+
+	for _, el := range els {
+		if err := q.Enqueue(el); err != nil {
+			tb.Errorf("unexpected enqueue error: %v", err)
+		}
+	}
+}
+
 func Test_QueueEnqueueDequeue(t *testing.T) {
 	q := queue.New[int]()
-	q.Enqueue(1)
-	q.Enqueue(2)
-	q.Enqueue(3)
+	enqueue(t, q, 1, 2, 3)
 
 	if size := q.Size(); size != 3 {
 		t.Errorf("expected size 3, got %d", size)
@@ -37,8 +46,7 @@ func Test_QueueEnqueueDequeue(t *testing.T) {
 
 func Test_QueueClear(t *testing.T) {
 	q := queue.New[int]()
-	q.Enqueue(10)
-	q.Enqueue(20)
+	enqueue(t, q, 10, 20)
 	q.Clear()
 
 	if !q.IsEmpty() {
@@ -60,8 +68,7 @@ func Test_QueueTryDequeue(t *testing.T) {
 	}
 
 	// Enqueue some elements
-	q.Enqueue(100)
-	q.Enqueue(200)
+	enqueue(t, q, 100, 200)
 
 	// TryDequeue should return first element
 	val, ok = q.TryDequeue()
@@ -126,7 +133,7 @@ func Test_QueueDequeue_ConcurrentEnqueueDequeue(t *testing.T) {
 	// Enqueue in goroutine
 	go func() {
 		for i := range n {
-			q.Enqueue(i)
+			enqueue(t, q, i)
 		}
 		wg.Done()
 	}()
@@ -159,7 +166,7 @@ func Test_QueueTryDequeue_Concurrent(t *testing.T) {
 
 	go func() {
 		for i := range n {
-			q.Enqueue(i)
+			enqueue(t, q, i)
 		}
 		wg.Done()
 	}()
@@ -183,3 +190,524 @@ func Test_QueueTryDequeue_Concurrent(t *testing.T) {
 		t.Errorf("expected queue to be empty after concurrent TryDequeue")
 	}
 }
+
+func Test_QueueEnqueueAllDequeueN(t *testing.T) {
+	q := queue.New[int]()
+	if err := q.EnqueueAll(1, 2, 3, 4, 5); err != nil {
+		t.Error(err)
+	}
+
+	if size := q.Size(); size != 5 {
+		t.Fatalf("expected size 5, got %d", size)
+	}
+
+	items, err := q.DequeueN(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[0] != 1 || items[2] != 3 {
+		t.Fatalf("expected [1 2 3], got %v", items)
+	}
+
+	items, err = q.DequeueN(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0] != 4 || items[1] != 5 {
+		t.Fatalf("expected [4 5], got %v", items)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := q.DequeueN(ctx, 1); err != queue.ErrTimeout {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+}
+
+func Test_QueueDequeueNBlocking(t *testing.T) {
+	q := queue.New[int]()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		if err := q.EnqueueAll(1, 2); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	items, err := q.DequeueN(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %v", items)
+	}
+}
+
+func Test_QueueDequeueBatch(t *testing.T) {
+	q := queue.New[int]()
+	enqueue(t, q, 1)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		enqueue(t, q, 2)
+		time.Sleep(10 * time.Millisecond)
+		enqueue(t, q, 3)
+	}()
+
+	items, err := q.DequeueBatch(context.Background(), 3, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[0] != 1 || items[1] != 2 || items[2] != 3 {
+		t.Fatalf("expected [1 2 3], got %v", items)
+	}
+
+	enqueue(t, q, 4)
+
+	start := time.Now()
+	items, err = q.DequeueBatch(context.Background(), 3, 30*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0] != 4 {
+		t.Fatalf("expected [4], got %v", items)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected to linger for 30ms, got %v", elapsed)
+	}
+}
+
+func Test_QueueRingBuffer(t *testing.T) {
+	q := queue.New[int](queue.WithRingBuffer(2))
+
+	enqueue(t, q, 1, 2)
+
+	if val, ok := q.TryDequeue(); !ok || val != 1 {
+		t.Fatalf("expected 1, got %v %v", val, ok)
+	}
+
+	// Wrap around, then force the buffer to grow
+	if err := q.EnqueueAll(3, 4, 5, 6); err != nil {
+		t.Error(err)
+	}
+
+	if size := q.Size(); size != 5 {
+		t.Fatalf("expected size 5, got %d", size)
+	}
+
+	items, err := q.DequeueN(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, val := range items {
+		if val != i+2 {
+			t.Fatalf("expected %d, got %v", i+2, items)
+		}
+	}
+
+	enqueue(t, q, 7)
+	q.Clear()
+
+	if !q.IsEmpty() {
+		t.Fatal("expected queue to be empty after Clear")
+	}
+}
+
+func Test_QueueRingBuffer_ConcurrentEnqueueDequeue(t *testing.T) {
+	q := queue.New[int](queue.WithRingBuffer(4))
+	const n = 1000
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		for i := range n {
+			enqueue(t, q, i)
+		}
+		wg.Done()
+	}()
+
+	go func() {
+		for i := range n {
+			val, err := q.Dequeue(context.Background())
+			if err != nil || val != i {
+				t.Errorf("expected %d, got %d %v", i, val, err)
+			}
+		}
+		wg.Done()
+	}()
+
+	wg.Wait()
+	if !q.IsEmpty() {
+		t.Errorf("expected queue to be empty after concurrent ops")
+	}
+}
+
+func benchmarkQueue(b *testing.B, newQueue func() queue.Queue[int]) {
+	b.Run("Sequential", func(b *testing.B) {
+		q := newQueue()
+		for i := range b.N {
+			if err := q.Enqueue(i); err != nil {
+				b.Error(err)
+			}
+			q.TryDequeue()
+		}
+	})
+
+	b.Run("Parallel", func(b *testing.B) {
+		q := newQueue()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				if err := q.Enqueue(i); err != nil {
+					b.Error(err)
+				}
+				q.TryDequeue()
+				i++
+			}
+		})
+	})
+
+	b.Run("ProducerConsumer", func(b *testing.B) {
+		q := newQueue()
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+			for range b.N {
+				if _, err := q.Dequeue(context.Background()); err != nil {
+					return
+				}
+			}
+		}()
+
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				if err := q.Enqueue(i); err != nil {
+					b.Error(err)
+				}
+				i++
+			}
+		})
+
+		<-done
+	})
+}
+
+func Benchmark_QueueLinkedList(b *testing.B) {
+	benchmarkQueue(b, func() queue.Queue[int] { return queue.New[int]() })
+}
+
+func Benchmark_QueueRingBuffer(b *testing.B) {
+	benchmarkQueue(b, func() queue.Queue[int] { return queue.New[int](queue.WithRingBuffer(1024)) })
+}
+
+func Test_QueueClose(t *testing.T) {
+	q := queue.New[int]()
+	enqueue(t, q, 1, 2)
+
+	q.Close()
+
+	if err := q.Enqueue(3); err != queue.ErrClosed {
+		t.Fatalf("expected ErrClosed on Enqueue, got %v", err)
+	}
+
+	if err := q.EnqueueAll(3, 4); err != queue.ErrClosed {
+		t.Fatalf("expected ErrClosed on EnqueueAll, got %v", err)
+	}
+
+	// Remaining items are still drained
+	for i := 1; i <= 2; i++ {
+		if val, err := q.Dequeue(context.Background()); err != nil || val != i {
+			t.Fatalf("expected %d, got %v %v", i, val, err)
+		}
+	}
+
+	if _, err := q.Dequeue(context.Background()); err != queue.ErrClosed {
+		t.Fatalf("expected ErrClosed on Dequeue, got %v", err)
+	}
+
+	if _, err := q.DequeueBatch(context.Background(), 5, time.Second); err != queue.ErrClosed {
+		t.Fatalf("expected ErrClosed on DequeueBatch, got %v", err)
+	}
+}
+
+func Test_QueueCloseWakesConsumers(t *testing.T) {
+	q := queue.New[int]()
+
+	const consumers = 3
+	errs := make(chan error, consumers)
+
+	for range consumers {
+		go func() {
+			_, err := q.Dequeue(context.Background())
+			errs <- err
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	q.Close()
+
+	for range consumers {
+		select {
+		case err := <-errs:
+			if err != queue.ErrClosed {
+				t.Fatalf("expected ErrClosed, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("consumer was not woken up by Close")
+		}
+	}
+}
+
+func Test_QueueAll(t *testing.T) {
+	q := queue.New[int](queue.WithRingBuffer(4))
+
+	go func() {
+		for i := range 10 {
+			enqueue(t, q, i)
+		}
+		q.Close()
+	}()
+
+	expected := 0
+	for val := range q.All(context.Background()) {
+		if val != expected {
+			t.Fatalf("expected %d, got %d", expected, val)
+		}
+		expected++
+	}
+
+	if expected != 10 {
+		t.Fatalf("expected 10 items, got %d", expected)
+	}
+}
+
+func Test_QueueAllBreakAndCancel(t *testing.T) {
+	q := queue.New[int]()
+	enqueue(t, q, 1, 2, 3)
+
+	for val := range q.All(context.Background()) {
+		if val == 2 {
+			break
+		}
+	}
+
+	if size := q.Size(); size != 1 {
+		t.Fatalf("expected 1 remaining item, got %d", size)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	count := 0
+	for range q.All(ctx) {
+		count++
+	}
+
+	if count != 1 {
+		t.Fatalf("expected 1 item before cancellation, got %d", count)
+	}
+}
+
+func Test_QueuePeek(t *testing.T) {
+	q := queue.New[int]()
+
+	if _, ok := q.TryPeek(); ok {
+		t.Fatal("expected TryPeek to fail on empty queue")
+	}
+
+	enqueue(t, q, 1, 2)
+
+	if val, ok := q.TryPeek(); !ok || val != 1 {
+		t.Fatalf("expected 1, got %v %v", val, ok)
+	}
+
+	if size := q.Size(); size != 2 {
+		t.Fatalf("expected size 2 after peek, got %d", size)
+	}
+}
+
+func Test_QueuePeekBlocking(t *testing.T) {
+	q := queue.New[int]()
+
+	peeked := make(chan int)
+	dequeued := make(chan int)
+
+	go func() {
+		val, _ := q.Peek(context.Background())
+		peeked <- val
+	}()
+
+	go func() {
+		val, _ := q.Dequeue(context.Background())
+		dequeued <- val
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	enqueue(t, q, 7)
+
+	// A waiting Peek must not swallow the wake-up meant for Dequeue
+	select {
+	case val := <-dequeued:
+		if val != 7 {
+			t.Fatalf("expected dequeue 7, got %d", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dequeue was not woken up")
+	}
+
+	enqueue(t, q, 8)
+
+	select {
+	case val := <-peeked:
+		if val != 7 && val != 8 {
+			t.Fatalf("expected peek 7 or 8, got %d", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Peek was not woken up")
+	}
+
+	q.Clear()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := q.Peek(ctx); err != queue.ErrTimeout {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+}
+
+func Test_QueueDrainSnapshot(t *testing.T) {
+	for _, q := range []queue.Queue[int]{queue.New[int](), queue.New[int](queue.WithRingBuffer(2))} {
+		enqueue(t, q, 1, 2, 3)
+		q.TryDequeue()
+		enqueue(t, q, 4)
+
+		snapshot := q.Snapshot()
+		if len(snapshot) != 3 || snapshot[0] != 2 || snapshot[2] != 4 {
+			t.Fatalf("expected [2 3 4], got %v", snapshot)
+		}
+
+		if size := q.Size(); size != 3 {
+			t.Fatalf("expected size 3 after snapshot, got %d", size)
+		}
+
+		drained := q.Drain()
+		if len(drained) != 3 || drained[0] != 2 || drained[2] != 4 {
+			t.Fatalf("expected [2 3 4], got %v", drained)
+		}
+
+		if !q.IsEmpty() {
+			t.Fatal("expected queue to be empty after Drain")
+		}
+	}
+}
+
+func Test_QueueFairness(t *testing.T) {
+	q := queue.New[int](queue.WithFairness())
+
+	const consumers = 5
+	results := make([]chan int, consumers)
+
+	for i := range consumers {
+		results[i] = make(chan int, 1)
+
+		go func(out chan int) {
+			val, err := q.Dequeue(context.Background())
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			out <- val
+		}(results[i])
+
+		// Let the consumer register before starting the next one
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for i := range consumers {
+		enqueue(t, q, i)
+	}
+
+	for i := range consumers {
+		if val := <-results[i]; val != i {
+			t.Fatalf("consumer %d expected %d, got %d", i, i, val)
+		}
+	}
+
+	if !q.IsEmpty() {
+		t.Fatal("expected handed over items to bypass the queue")
+	}
+}
+
+func Test_QueueFairnessCancelAndClose(t *testing.T) {
+	q := queue.New[int](queue.WithFairness())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := q.Dequeue(ctx); err != queue.ErrTimeout {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+
+	enqueue(t, q, 1)
+
+	if val, ok := q.TryDequeue(); !ok || val != 1 {
+		t.Fatalf("expected item to be stored once the waiter gave up, got %v %v", val, ok)
+	}
+
+	errs := make(chan error)
+	go func() {
+		_, err := q.Dequeue(context.Background())
+		errs <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+
+	if err := <-errs; err != queue.ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func Test_QueueFairness_ConcurrentEnqueueDequeue(t *testing.T) {
+	q := queue.New[int](queue.WithFairness())
+	const n = 1000
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		for i := range n {
+			enqueue(t, q, i)
+		}
+		wg.Done()
+	}()
+
+	go func() {
+		for i := 0; i < n; {
+			items, err := q.DequeueN(context.Background(), 10)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				break
+			}
+			for _, val := range items {
+				if val != i {
+					t.Errorf("expected %d, got %d", i, val)
+				}
+				i++
+			}
+		}
+		wg.Done()
+	}()
+
+	wg.Wait()
+	if !q.IsEmpty() {
+		t.Errorf("expected queue to be empty after concurrent ops")
+	}
+}
+
+func Benchmark_QueueFair(b *testing.B) {
+	benchmarkQueue(b, func() queue.Queue[int] { return queue.New[int](queue.WithFairness()) })
+}
@@ -0,0 +1,90 @@
+package queue
+
+const defaultRingCapacity = 16
+
+type ring[T any] struct {
+	buf  []T
+	head int
+	size int
+}
+
+func (r *ring[T]) grow() {
+	buf := make([]T, max(len(r.buf)*2, defaultRingCapacity))
+
+	n := copy(buf, r.buf[r.head:])
+	copy(buf[n:], r.buf[:r.head])
+
+	r.buf = buf
+	r.head = 0
+}
+
+func (r *ring[T]) push(el T) {
+	if r.size == len(r.buf) {
+		r.grow()
+	}
+
+	r.buf[(r.head+r.size)%len(r.buf)] = el
+	r.size++
+}
+
+func (r *ring[T]) pushFront(el T) {
+	if r.size == len(r.buf) {
+		r.grow()
+	}
+
+	r.head = (r.head - 1 + len(r.buf)) % len(r.buf)
+	r.buf[r.head] = el
+	r.size++
+}
+
+func (r *ring[T]) pop() (T, bool) {
+	var zero T
+
+	if r.size == 0 {
+		return zero, false
+	}
+
+	el := r.buf[r.head]
+	r.buf[r.head] = zero
+	r.head = (r.head + 1) % len(r.buf)
+	r.size--
+
+	return el, true
+}
+
+func (r *ring[T]) peek() (T, bool) {
+	if r.size == 0 {
+		var zero T
+		return zero, false
+	}
+	return r.buf[r.head], true
+}
+
+func (r *ring[T]) values() []T {
+	values := make([]T, 0, r.size)
+	for i := range r.size {
+		values = append(values, r.buf[(r.head+i)%len(r.buf)])
+	}
+	return values
+}
+
+func (r *ring[T]) len() int {
+	return r.size
+}
+
+func (r *ring[T]) full() bool {
+	return r.size == len(r.buf)
+}
+
+func (r *ring[T]) clear() {
+	clear(r.buf)
+	r.head = 0
+	r.size = 0
+}
+
+func newRing[T any](capacity int) *ring[T] {
+	if capacity < 1 {
+		capacity = defaultRingCapacity
+	}
+	return &ring[T]{buf: make([]T, capacity)}
+}
@@ -0,0 +1,254 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/provincialig/golimitless/clock"
+)
+
+const defaultVisibilityTimeout = 30 * time.Second
+
+var (
+	ErrUnknownReceipt = errors.New("unknown receipt")
+)
+
+type Receipt uint64
+
+type Message[T any] struct {
+	Value    T
+	Receipt  Receipt
+	Attempts int
+}
+
+type WorkQueue[T any] interface {
+	Send(el T) error
+	Receive(ctx context.Context) (Message[T], error)
+	Ack(receipt Receipt) error
+	Nack(receipt Receipt) error
+	DeadLetter() Queue[T]
+	Close()
+	Size() int
+	InFlight() int
+}
+
+type WorkOption func(c *workConfig)
+
+type workConfig struct {
+	visibility  time.Duration
+	maxAttempts int
+	clock       clock.Clock
+}
+
+func WithVisibilityTimeout(d time.Duration) WorkOption {
+	return func(c *workConfig) {
+		if d > 0 {
+			c.visibility = d
+		}
+	}
+}
+
+// WithMaxAttempts moves a message to the dead-letter queue after n failed deliveries.
+func WithMaxAttempts(n int) WorkOption {
+	return func(c *workConfig) {
+		c.maxAttempts = max(n, 0)
+	}
+}
+
+func WithClock(c clock.Clock) WorkOption {
+	return func(config *workConfig) {
+		config.clock = clock.OrDefault(c)
+	}
+}
+
+type workItem[T any] struct {
+	value    T
+	attempts int
+	timeout  DelayHandle[Receipt]
+}
+
+type workQueue[T any] struct {
+	config workConfig
+
+	ready      Queue[*workItem[T]]
+	timeouts   DelayQueue[Receipt]
+	deadLetter Queue[T]
+
+	mut      sync.Mutex
+	next     Receipt
+	inFlight map[Receipt]*workItem[T]
+	pending  int
+	closed   bool
+
+	// done ends once the queue is closed and every message is acked or dead-lettered
+	done   context.Context
+	cancel context.CancelFunc
+}
+
+func (q *workQueue[T]) reaper() {
+	for {
+		receipt, err := q.timeouts.Dequeue(q.done)
+		if err != nil {
+			return
+		}
+
+		_ = q.Nack(receipt)
+	}
+}
+
+func (q *workQueue[T]) Send(el T) error {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	if err := q.ready.Enqueue(&workItem[T]{value: el}); err != nil {
+		return err
+	}
+
+	q.pending++
+
+	return nil
+}
+
+func (q *workQueue[T]) Receive(ctx context.Context) (Message[T], error) {
+	dequeueCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := context.AfterFunc(q.done, cancel)
+	defer stop()
+
+	item, err := q.ready.Dequeue(dequeueCtx)
+	if err != nil {
+		if ctx.Err() == nil && q.done.Err() != nil {
+			return Message[T]{}, ErrClosed
+		}
+		return Message[T]{}, err
+	}
+
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	q.next++
+	item.attempts++
+	item.timeout = q.timeouts.EnqueueAfter(q.next, q.config.visibility)
+	q.inFlight[q.next] = item
+
+	return Message[T]{
+		Value:    item.value,
+		Receipt:  q.next,
+		Attempts: item.attempts,
+	}, nil
+}
+
+// finishUnsafe forgets a message for good and stops the queue once it is closed and drained.
+func (q *workQueue[T]) finishUnsafe(receipt Receipt, item *workItem[T]) {
+	delete(q.inFlight, receipt)
+	q.timeouts.Remove(item.timeout)
+
+	q.pending--
+	if q.closed && q.pending == 0 {
+		q.cancel()
+	}
+}
+
+func (q *workQueue[T]) Ack(receipt Receipt) error {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	item, ok := q.inFlight[receipt]
+	if !ok {
+		return ErrUnknownReceipt
+	}
+
+	q.finishUnsafe(receipt, item)
+
+	return nil
+}
+
+func (q *workQueue[T]) Nack(receipt Receipt) error {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	item, ok := q.inFlight[receipt]
+	if !ok {
+		return ErrUnknownReceipt
+	}
+
+	if q.config.maxAttempts > 0 && item.attempts >= q.config.maxAttempts {
+		if err := q.deadLetter.Enqueue(item.value); err != nil {
+			return err
+		}
+
+		q.finishUnsafe(receipt, item)
+
+		return nil
+	}
+
+	// The message stays in flight, and keeps its timeout, unless it is back in the ready queue
+	if err := q.ready.Enqueue(item); err != nil {
+		return err
+	}
+
+	delete(q.inFlight, receipt)
+	q.timeouts.Remove(item.timeout)
+
+	return nil
+}
+
+func (q *workQueue[T]) DeadLetter() Queue[T] {
+	return q.deadLetter
+}
+
+// Close rejects new messages, the ones already sent are still delivered, redelivered and timed out
+// until they are acked or dead-lettered, then Receive returns ErrClosed.
+func (q *workQueue[T]) Close() {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	q.closed = true
+
+	if q.pending == 0 {
+		q.cancel()
+	}
+}
+
+func (q *workQueue[T]) Size() int {
+	return q.ready.Size()
+}
+
+func (q *workQueue[T]) InFlight() int {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	return len(q.inFlight)
+}
+
+func NewWork[T any](opts ...WorkOption) WorkQueue[T] {
+	config := workConfig{
+		visibility: defaultVisibilityTimeout,
+		clock:      clock.New(),
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	done, cancel := context.WithCancel(context.Background())
+
+	q := &workQueue[T]{
+		config:     config,
+		ready:      New[*workItem[T]](),
+		timeouts:   NewDelayWithClock[Receipt](config.clock),
+		deadLetter: New[T](),
+		inFlight:   map[Receipt]*workItem[T]{},
+		done:       done,
+		cancel:     cancel,
+	}
+	go q.reaper()
+
+	return q
+}
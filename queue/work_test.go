@@ -0,0 +1,199 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/clock"
+	"github.com/provincialig/golimitless/queue"
+)
+
+func receive[T any](t *testing.T, q queue.WorkQueue[T]) queue.Message[T] {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	msg, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return msg
+}
+
+func Test_WorkAck(t *testing.T) {
+	q := queue.NewWork[string]()
+	defer q.Close()
+
+	_ = q.Send("job")
+
+	msg := receive(t, q)
+	if msg.Value != "job" || msg.Attempts != 1 {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+
+	if q.Size() != 0 || q.InFlight() != 1 {
+		t.Fatalf("expected 0 ready and 1 in flight, got %d %d", q.Size(), q.InFlight())
+	}
+
+	if err := q.Ack(msg.Receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Ack(msg.Receipt); !errors.Is(err, queue.ErrUnknownReceipt) {
+		t.Fatalf("expected ErrUnknownReceipt, got %v", err)
+	}
+
+	if q.InFlight() != 0 {
+		t.Fatalf("expected nothing in flight, got %d", q.InFlight())
+	}
+}
+
+func Test_WorkNack(t *testing.T) {
+	q := queue.NewWork[string]()
+	defer q.Close()
+
+	_ = q.Send("job")
+
+	msg := receive(t, q)
+	if err := q.Nack(msg.Receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	msg = receive(t, q)
+	if msg.Value != "job" || msg.Attempts != 2 {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
+
+func Test_WorkVisibilityTimeout(t *testing.T) {
+	c := clock.NewFake(epoch)
+
+	q := queue.NewWork[string](queue.WithVisibilityTimeout(time.Minute), queue.WithClock(c))
+	defer q.Close()
+
+	_ = q.Send("job")
+
+	first := receive(t, q)
+
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+
+	second := receive(t, q)
+	if second.Value != "job" || second.Attempts != 2 {
+		t.Fatalf("unexpected message: %+v", second)
+	}
+
+	if err := q.Ack(first.Receipt); !errors.Is(err, queue.ErrUnknownReceipt) {
+		t.Fatalf("expected stale receipt to be rejected, got %v", err)
+	}
+
+	if err := q.Ack(second.Receipt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_WorkDeadLetter(t *testing.T) {
+	q := queue.NewWork[string](queue.WithMaxAttempts(2))
+	defer q.Close()
+
+	_ = q.Send("poison")
+
+	for range 2 {
+		msg := receive(t, q)
+		if err := q.Nack(msg.Receipt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if q.Size() != 0 {
+		t.Fatalf("expected no ready message, got %d", q.Size())
+	}
+
+	if val, ok := q.DeadLetter().TryDequeue(); !ok || val != "poison" {
+		t.Fatalf("expected poison in dead-letter queue, got %v %v", val, ok)
+	}
+}
+
+func Test_WorkClose(t *testing.T) {
+	q := queue.NewWork[int]()
+	q.Close()
+
+	if err := q.Send(1); !errors.Is(err, queue.ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+
+	if _, err := q.Receive(context.Background()); !errors.Is(err, queue.ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func Test_WorkCloseKeepsInFlight(t *testing.T) {
+	c := clock.NewFake(epoch)
+
+	q := queue.NewWork[string](queue.WithVisibilityTimeout(time.Minute), queue.WithClock(c))
+	_ = q.Send("nacked")
+	_ = q.Send("timed out")
+
+	first := receive(t, q)
+	second := receive(t, q)
+
+	q.Close()
+
+	if err := q.Nack(first.Receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Size() != 1 || q.InFlight() != 1 {
+		t.Fatalf("expected 1 ready and 1 in flight, got %d %d", q.Size(), q.InFlight())
+	}
+
+	redelivered := receive(t, q)
+	if redelivered.Value != first.Value || redelivered.Attempts != 2 {
+		t.Fatalf("unexpected message: %+v", redelivered)
+	}
+	_ = q.Ack(redelivered.Receipt)
+
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+
+	timedOut := receive(t, q)
+	if timedOut.Value != second.Value || timedOut.Attempts != 2 {
+		t.Fatalf("unexpected message: %+v", timedOut)
+	}
+
+	if err := q.Ack(timedOut.Receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := q.Receive(context.Background()); !errors.Is(err, queue.ErrClosed) {
+		t.Fatalf("expected ErrClosed once drained, got %v", err)
+	}
+}
+
+func Test_WorkAckRemovesTimeout(t *testing.T) {
+	c := clock.NewFake(epoch)
+
+	q := queue.NewWork[string](queue.WithVisibilityTimeout(time.Minute), queue.WithClock(c))
+	defer q.Close()
+
+	_ = q.Send("job")
+
+	msg := receive(t, q)
+	c.BlockUntil(1)
+
+	if err := q.Ack(msg.Receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reaper drops its timer once the timeout is removed
+	deadline := time.Now().Add(time.Second)
+	for c.Waiters() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the acked timeout to be removed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
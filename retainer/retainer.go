@@ -2,10 +2,11 @@ package retainer
 
 import (
 	"context"
-	"errors"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/provincialig/golimitless/clock"
 	"github.com/provincialig/golimitless/queue"
 )
 
@@ -24,26 +25,37 @@ type myRetainer[T comparable] struct {
 	out queue.Queue[T]
 	m   map[T]time.Time
 
-	mut sync.Mutex
-	t   *time.Ticker
+	mut   sync.Mutex
+	clock clock.Clock
+	t     clock.Ticker
 
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
 func (r *myRetainer[T]) worker() {
-	for range r.t.C {
-		now := time.Now()
+	for range r.t.C() {
+		now := r.clock.Now()
 
 		r.mut.Lock()
 
+		expired := []T{}
 		for k, v := range r.m {
 			if now.After(v) {
-				delete(r.m, k)
-				r.out.Enqueue(k)
+				expired = append(expired, k)
 			}
 		}
 
+		// Emit in expiry order, map iteration is random
+		slices.SortFunc(expired, func(a, b T) int {
+			return r.m[a].Compare(r.m[b])
+		})
+
+		for _, k := range expired {
+			delete(r.m, k)
+			_ = r.out.Enqueue(k)
+		}
+
 		r.mut.Unlock()
 	}
 }
@@ -56,7 +68,7 @@ func (r *myRetainer[T]) Add(data T, retain time.Duration) {
 		return
 	}
 
-	r.m[data] = time.Now().Add(retain)
+	r.m[data] = r.clock.Now().Add(retain)
 }
 
 func (r *myRetainer[T]) Get() (<-chan T, CancelFunc) {
@@ -66,20 +78,7 @@ func (r *myRetainer[T]) Get() (<-chan T, CancelFunc) {
 
 	ctx, cancel := context.WithCancel(r.ctx)
 
-	out := make(chan T)
-
-	go func() {
-		defer close(out)
-
-		for {
-			data, err := r.out.Dequeue(ctx)
-			if errors.Is(err, queue.ErrCanceled) {
-				return
-			}
-
-			out <- data
-		}
-	}()
+	out := queue.ToChannel(ctx, r.out, 0)
 
 	return out, cancel
 }
@@ -113,13 +112,20 @@ func (r *myRetainer[T]) Destroy() {
 }
 
 func New[T comparable]() Retainer[T] {
+	return NewWithClock[T](clock.New())
+}
+
+func NewWithClock[T comparable](c clock.Clock) Retainer[T] {
 	ctx, cancel := context.WithCancel(context.Background())
 
+	c = clock.OrDefault(c)
+
 	retainer := &myRetainer[T]{
 		start:  true,
 		out:    queue.New[T](),
 		m:      map[T]time.Time{},
-		t:      time.NewTicker(100 * time.Millisecond),
+		clock:  c,
+		t:      c.NewTicker(100 * time.Millisecond),
 		ctx:    ctx,
 		cancel: cancel,
 	}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/provincialig/golimitless/clock"
 	"github.com/provincialig/golimitless/retainer"
 )
 
@@ -270,3 +271,63 @@ func TestRetainerMultipleConsumers(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRetainerFakeClock(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	r := retainer.NewWithClock[int](c)
+	defer r.Destroy()
+
+	r.Add(7, time.Hour)
+
+	ch, cancel := r.Get()
+	defer cancel()
+
+	c.Advance(30 * time.Minute)
+
+	select {
+	case v := <-ch:
+		t.Fatalf("unexpected value before retain time: %v", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.Advance(30*time.Minute + 100*time.Millisecond)
+
+	select {
+	case v := <-ch:
+		if v != 7 {
+			t.Errorf("expected 7, got %v", v)
+		}
+	case <-time.After(time.Second):
+		t.Error("timeout waiting for value")
+	}
+}
+
+func TestRetainerExpiryOrder(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	r := retainer.NewWithClock[int](c)
+	defer r.Destroy()
+
+	r.Add(3, 3*time.Second)
+	r.Add(1, time.Second)
+	r.Add(4, 4*time.Second)
+	r.Add(2, 2*time.Second)
+
+	ch, cancel := r.Get()
+	defer cancel()
+
+	// A single tick expires every item at once
+	c.Advance(5 * time.Second)
+
+	for i := 1; i <= 4; i++ {
+		select {
+		case v := <-ch:
+			if v != i {
+				t.Fatalf("expected %v, got %v", i, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for value")
+		}
+	}
+}
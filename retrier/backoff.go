@@ -0,0 +1,118 @@
+package retrier
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+type Backoff interface {
+	Next(attempt int, prev time.Duration) time.Duration
+}
+
+type BackoffFunc func(attempt int, prev time.Duration) time.Duration
+
+func (f BackoffFunc) Next(attempt int, prev time.Duration) time.Duration {
+	return f(attempt, prev)
+}
+
+type RandFunc func() float64
+
+func orDefaultRand(rnd RandFunc) RandFunc {
+	if rnd == nil {
+		return rand.Float64
+	}
+	return rnd
+}
+
+func capDelay(d time.Duration, maxDelay time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	if maxDelay > 0 && d > maxDelay {
+		return maxDelay
+	}
+	return d
+}
+
+func exponential(base time.Duration, maxDelay time.Duration, factor float64, attempt int) time.Duration {
+	d := float64(base) * math.Pow(factor, float64(attempt-1))
+	if d >= math.MaxInt64 || math.IsInf(d, 0) || math.IsNaN(d) {
+		if maxDelay > 0 {
+			return maxDelay
+		}
+		return time.Duration(math.MaxInt64)
+	}
+	return capDelay(time.Duration(d), maxDelay)
+}
+
+func Constant(delay time.Duration) Backoff {
+	return BackoffFunc(func(int, time.Duration) time.Duration {
+		return delay
+	})
+}
+
+func Exponential(base time.Duration, maxDelay time.Duration, factor float64) Backoff {
+	if factor < 1 {
+		factor = 2
+	}
+
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		return exponential(base, maxDelay, factor, attempt)
+	})
+}
+
+func FullJitter(base time.Duration, maxDelay time.Duration, rnd RandFunc) Backoff {
+	rnd = orDefaultRand(rnd)
+
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		d := exponential(base, maxDelay, 2, attempt)
+		return time.Duration(rnd() * float64(d))
+	})
+}
+
+func EqualJitter(base time.Duration, maxDelay time.Duration, rnd RandFunc) Backoff {
+	rnd = orDefaultRand(rnd)
+
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		d := exponential(base, maxDelay, 2, attempt)
+		half := d / 2
+		return half + time.Duration(rnd()*float64(d-half))
+	})
+}
+
+func DecorrelatedJitter(base time.Duration, maxDelay time.Duration, rnd RandFunc) Backoff {
+	rnd = orDefaultRand(rnd)
+
+	return BackoffFunc(func(_ int, prev time.Duration) time.Duration {
+		if prev < base {
+			prev = base
+		}
+
+		upper := float64(prev) * 3
+		if maxDelay > 0 && upper > float64(maxDelay) {
+			upper = float64(maxDelay)
+		}
+		if upper < float64(base) {
+			return capDelay(base, maxDelay)
+		}
+
+		return capDelay(base+time.Duration(rnd()*(upper-float64(base))), maxDelay)
+	})
+}
+
+func Fibonacci(base time.Duration, maxDelay time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		a, b := time.Duration(0), base
+		for range attempt {
+			if maxDelay > 0 && b >= maxDelay {
+				return maxDelay
+			}
+			if b > math.MaxInt64-a {
+				return capDelay(time.Duration(math.MaxInt64), maxDelay)
+			}
+			a, b = b, a+b
+		}
+		return capDelay(a, maxDelay)
+	})
+}
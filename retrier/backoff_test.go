@@ -0,0 +1,91 @@
+package retrier_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/retrier"
+)
+
+func fixedRand(v float64) retrier.RandFunc {
+	return func() float64 { return v }
+}
+
+func Test_BackoffConstant(t *testing.T) {
+	b := retrier.Constant(10 * time.Millisecond)
+
+	for attempt := 1; attempt <= 5; attempt++ {
+		if d := b.Next(attempt, 0); d != 10*time.Millisecond {
+			t.Fatalf("attempt %d: expected 10ms, got %v", attempt, d)
+		}
+	}
+}
+
+func Test_BackoffExponential(t *testing.T) {
+	b := retrier.Exponential(10*time.Millisecond, 100*time.Millisecond, 2)
+
+	expected := []time.Duration{10, 20, 40, 80, 100, 100}
+	for i, e := range expected {
+		if d := b.Next(i+1, 0); d != e*time.Millisecond {
+			t.Fatalf("attempt %d: expected %v, got %v", i+1, e*time.Millisecond, d)
+		}
+	}
+
+	if d := b.Next(1000, 0); d != 100*time.Millisecond {
+		t.Fatalf("expected cap on overflow, got %v", d)
+	}
+}
+
+func Test_BackoffFullJitter(t *testing.T) {
+	b := retrier.FullJitter(10*time.Millisecond, time.Second, fixedRand(0.5))
+
+	if d := b.Next(3, 0); d != 20*time.Millisecond {
+		t.Fatalf("expected 20ms, got %v", d)
+	}
+
+	b = retrier.FullJitter(10*time.Millisecond, time.Second, fixedRand(0))
+	if d := b.Next(3, 0); d != 0 {
+		t.Fatalf("expected 0, got %v", d)
+	}
+}
+
+func Test_BackoffEqualJitter(t *testing.T) {
+	b := retrier.EqualJitter(10*time.Millisecond, time.Second, fixedRand(0))
+	if d := b.Next(3, 0); d != 20*time.Millisecond {
+		t.Fatalf("expected 20ms, got %v", d)
+	}
+
+	b = retrier.EqualJitter(10*time.Millisecond, time.Second, fixedRand(1))
+	if d := b.Next(3, 0); d != 40*time.Millisecond {
+		t.Fatalf("expected 40ms, got %v", d)
+	}
+}
+
+func Test_BackoffDecorrelatedJitter(t *testing.T) {
+	b := retrier.DecorrelatedJitter(10*time.Millisecond, 50*time.Millisecond, fixedRand(1))
+
+	prev := time.Duration(0)
+	expected := []time.Duration{30, 50, 50}
+	for i, e := range expected {
+		prev = b.Next(i+1, prev)
+		if prev != e*time.Millisecond {
+			t.Fatalf("attempt %d: expected %v, got %v", i+1, e*time.Millisecond, prev)
+		}
+	}
+
+	b = retrier.DecorrelatedJitter(10*time.Millisecond, 50*time.Millisecond, fixedRand(0))
+	if d := b.Next(5, 40*time.Millisecond); d != 10*time.Millisecond {
+		t.Fatalf("expected base delay, got %v", d)
+	}
+}
+
+func Test_BackoffFibonacci(t *testing.T) {
+	b := retrier.Fibonacci(10*time.Millisecond, 60*time.Millisecond)
+
+	expected := []time.Duration{10, 10, 20, 30, 50, 60, 60}
+	for i, e := range expected {
+		if d := b.Next(i+1, 0); d != e*time.Millisecond {
+			t.Fatalf("attempt %d: expected %v, got %v", i+1, e*time.Millisecond, d)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package retrier
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/provincialig/golimitless/clock"
+)
+
+const budgetBuckets = 10
+
+var (
+	ErrBudgetExhausted = errors.New("retry budget exhausted")
+)
+
+type Budget interface {
+	Request()
+	TryRetry() bool
+}
+
+type budgetBucket struct {
+	epoch    int64
+	requests int
+	retries  int
+}
+
+type myBudget struct {
+	mut   sync.Mutex
+	clock clock.Clock
+
+	ratio      float64
+	minRetries int
+	bucketSize time.Duration
+
+	buckets [budgetBuckets]budgetBucket
+}
+
+func (b *myBudget) currentUnsafe(now time.Time) *budgetBucket {
+	epoch := now.UnixNano() / int64(b.bucketSize)
+	bucket := &b.buckets[epoch%budgetBuckets]
+
+	if bucket.epoch != epoch {
+		*bucket = budgetBucket{epoch: epoch}
+	}
+
+	return bucket
+}
+
+func (b *myBudget) totalsUnsafe(now time.Time) (int, int) {
+	epoch := now.UnixNano() / int64(b.bucketSize)
+	requests, retries := 0, 0
+
+	for _, bucket := range b.buckets {
+		if epoch-bucket.epoch < budgetBuckets {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+
+	return requests, retries
+}
+
+func (b *myBudget) Request() {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.currentUnsafe(b.clock.Now()).requests++
+}
+
+func (b *myBudget) TryRetry() bool {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	now := b.clock.Now()
+
+	requests, retries := b.totalsUnsafe(now)
+	if float64(retries) >= float64(b.minRetries)+b.ratio*float64(requests) {
+		return false
+	}
+
+	b.currentUnsafe(now).retries++
+
+	return true
+}
+
+func NewBudget(ratio float64, window time.Duration, minRetries int) Budget {
+	return NewBudgetWithClock(ratio, window, minRetries, clock.New())
+}
+
+func NewBudgetWithClock(ratio float64, window time.Duration, minRetries int, c clock.Clock) Budget {
+	bucketSize := window / budgetBuckets
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+
+	return &myBudget{
+		clock:      clock.OrDefault(c),
+		ratio:      ratio,
+		minRetries: minRetries,
+		bucketSize: bucketSize,
+	}
+}
+
+func WithBudget(budget Budget) Option {
+	return func(r *myRetrier) {
+		r.budget = budget
+	}
+}
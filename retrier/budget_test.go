@@ -0,0 +1,93 @@
+package retrier_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/clock"
+	"github.com/provincialig/golimitless/retrier"
+)
+
+func Test_BudgetRatio(t *testing.T) {
+	b := retrier.NewBudget(0.1, time.Minute, 0)
+
+	for range 20 {
+		b.Request()
+	}
+
+	if !b.TryRetry() || !b.TryRetry() {
+		t.Fatal("expected two retries to be allowed")
+	}
+
+	if b.TryRetry() {
+		t.Fatal("expected budget to be exhausted")
+	}
+}
+
+func Test_BudgetMinRetries(t *testing.T) {
+	b := retrier.NewBudget(0, time.Minute, 3)
+
+	for range 3 {
+		if !b.TryRetry() {
+			t.Fatal("expected retry to be allowed")
+		}
+	}
+
+	if b.TryRetry() {
+		t.Fatal("expected budget to be exhausted")
+	}
+}
+
+func Test_BudgetWindowExpire(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := retrier.NewBudgetWithClock(0, 50*time.Millisecond, 1, c)
+
+	if !b.TryRetry() {
+		t.Fatal("expected retry to be allowed")
+	}
+	if b.TryRetry() {
+		t.Fatal("expected budget to be exhausted")
+	}
+
+	c.Advance(70 * time.Millisecond)
+
+	if !b.TryRetry() {
+		t.Fatal("expected budget to be restored after the window")
+	}
+}
+
+func Test_BudgetShared(t *testing.T) {
+	errFail := errors.New("fail")
+	b := retrier.NewBudget(0, time.Minute, 2)
+
+	r1 := retrier.New(retrier.NO_DELAY, 10, retrier.WithBudget(b))
+	r2 := retrier.New(retrier.NO_DELAY, 10, retrier.WithBudget(b))
+
+	calls := 0
+	err := r1.Run(context.Background(), func() error {
+		calls++
+		return errFail
+	})
+	if !errors.Is(err, retrier.ErrBudgetExhausted) || !errors.Is(err, errFail) {
+		t.Fatalf("expected ErrBudgetExhausted wrapping the last error, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+
+	calls = 0
+	err = r2.Run(context.Background(), func() error {
+		calls++
+		return errFail
+	})
+	if !errors.Is(err, retrier.ErrBudgetExhausted) {
+		t.Fatalf("expected ErrBudgetExhausted, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected a single call without retries, got %d", calls)
+	}
+}
@@ -0,0 +1,57 @@
+package retrier
+
+import (
+	"fmt"
+	"time"
+)
+
+type AttemptError struct {
+	Attempt  int
+	Err      error
+	Start    time.Time
+	Duration time.Duration
+}
+
+func (e AttemptError) Error() string {
+	return fmt.Sprintf("attempt %d: %v", e.Attempt, e.Err)
+}
+
+func (e AttemptError) Unwrap() error {
+	return e.Err
+}
+
+type RetryError struct {
+	Attempts []AttemptError
+	Elapsed  time.Duration
+}
+
+func (e *RetryError) Error() string {
+	if len(e.Attempts) == 0 {
+		return ErrMaxRetry.Error()
+	}
+
+	return fmt.Sprintf("%v: %d attempts in %v, last error: %v", ErrMaxRetry, len(e.Attempts), e.Elapsed, e.Last())
+}
+
+func (e *RetryError) Is(target error) bool {
+	return target == ErrMaxRetry
+}
+
+func (e *RetryError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		errs = append(errs, attempt.Err)
+	}
+	return errs
+}
+
+func (e *RetryError) Count() int {
+	return len(e.Attempts)
+}
+
+func (e *RetryError) Last() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
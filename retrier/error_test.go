@@ -0,0 +1,64 @@
+package retrier_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/provincialig/golimitless/retrier"
+)
+
+func Test_RetryError(t *testing.T) {
+	errTarget := errors.New("target")
+	calls := 0
+
+	err := retrier.New(retrier.NO_DELAY, 3).Run(context.Background(), func() error {
+		calls++
+		if calls == 2 {
+			return errTarget
+		}
+		return fmt.Errorf("attempt %d failed", calls)
+	})
+
+	if !errors.Is(err, retrier.ErrMaxRetry) {
+		t.Fatalf("expected ErrMaxRetry, got %v", err)
+	}
+
+	if !errors.Is(err, errTarget) {
+		t.Fatalf("expected error chain to contain target error, got %v", err)
+	}
+
+	var retryErr *retrier.RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expected RetryError, got %T", err)
+	}
+
+	if retryErr.Count() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", retryErr.Count())
+	}
+
+	for i, attempt := range retryErr.Attempts {
+		if attempt.Attempt != i+1 {
+			t.Fatalf("expected attempt %d, got %d", i+1, attempt.Attempt)
+		}
+		if attempt.Start.IsZero() {
+			t.Fatalf("expected attempt %d start time", i+1)
+		}
+		if i > 0 && attempt.Start.Before(retryErr.Attempts[i-1].Start) {
+			t.Fatalf("attempt %d started before previous one", i+1)
+		}
+	}
+
+	if retryErr.Last().Error() != "attempt 3 failed" {
+		t.Fatalf("unexpected last error: %v", retryErr.Last())
+	}
+
+	if retryErr.Elapsed <= 0 {
+		t.Fatalf("expected positive elapsed time, got %v", retryErr.Elapsed)
+	}
+
+	if len(retryErr.Unwrap()) != 3 {
+		t.Fatalf("expected 3 unwrapped errors, got %d", len(retryErr.Unwrap()))
+	}
+}
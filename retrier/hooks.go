@@ -0,0 +1,66 @@
+package retrier
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+func OnRetry(fn func(attempt int, err error, next time.Duration)) Option {
+	return func(r *myRetrier) {
+		r.onRetry = fn
+	}
+}
+
+func OnGiveUp(fn func(attempt int, err error)) Option {
+	return func(r *myRetrier) {
+		r.onGiveUp = fn
+	}
+}
+
+func OnSuccess(fn func(attempt int, elapsed time.Duration)) Option {
+	return func(r *myRetrier) {
+		r.onSuccess = fn
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *myRetrier) {
+		r.logger = logger
+	}
+}
+
+func (r myRetrier) retrying(ctx context.Context, attempt int, err error, next time.Duration) {
+	if r.onRetry != nil {
+		r.onRetry(attempt, err, next)
+	}
+
+	if r.logger != nil {
+		r.logger.LogAttrs(ctx, slog.LevelWarn, "retrying",
+			slog.Int("attempt", attempt),
+			slog.Any("error", err),
+			slog.Duration("next_delay", next),
+		)
+	}
+}
+
+func (r myRetrier) giveUp(ctx context.Context, attempt int, err error) error {
+	if r.onGiveUp != nil {
+		r.onGiveUp(attempt, err)
+	}
+
+	if r.logger != nil {
+		r.logger.LogAttrs(ctx, slog.LevelError, "giving up",
+			slog.Int("attempt", attempt),
+			slog.Any("error", err),
+		)
+	}
+
+	return err
+}
+
+func (r myRetrier) succeeded(attempt int, elapsed time.Duration) {
+	if r.onSuccess != nil {
+		r.onSuccess(attempt, elapsed)
+	}
+}
@@ -0,0 +1,96 @@
+package retrier_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/retrier"
+)
+
+func Test_HooksSuccess(t *testing.T) {
+	retries := []int{}
+	successAttempt := 0
+
+	r := retrier.New(time.Millisecond, 5,
+		retrier.OnRetry(func(attempt int, err error, next time.Duration) {
+			if err == nil {
+				t.Error("expected retry error")
+			}
+			if next != time.Millisecond {
+				t.Errorf("expected next delay 1ms, got %v", next)
+			}
+			retries = append(retries, attempt)
+		}),
+		retrier.OnSuccess(func(attempt int, elapsed time.Duration) {
+			successAttempt = attempt
+		}),
+		retrier.OnGiveUp(func(attempt int, err error) {
+			t.Error("unexpected give up")
+		}),
+	)
+
+	calls := 0
+	err := r.Run(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("fail")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(retries) != 2 || retries[0] != 1 || retries[1] != 2 {
+		t.Fatalf("unexpected retries: %v", retries)
+	}
+
+	if successAttempt != 3 {
+		t.Fatalf("expected success on attempt 3, got %d", successAttempt)
+	}
+}
+
+func Test_HooksGiveUp(t *testing.T) {
+	giveUpAttempt := 0
+	var giveUpErr error
+
+	r := retrier.New(retrier.NO_DELAY, 2, retrier.OnGiveUp(func(attempt int, err error) {
+		giveUpAttempt = attempt
+		giveUpErr = err
+	}))
+
+	err := r.Run(context.Background(), func() error {
+		return errors.New("fail")
+	})
+
+	if giveUpAttempt != 2 {
+		t.Fatalf("expected give up on attempt 2, got %d", giveUpAttempt)
+	}
+
+	if giveUpErr != err || !errors.Is(giveUpErr, retrier.ErrMaxRetry) {
+		t.Fatalf("expected give up with returned error, got %v", giveUpErr)
+	}
+}
+
+func Test_WithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	_ = retrier.New(retrier.NO_DELAY, 2, retrier.WithLogger(logger)).Run(context.Background(), func() error {
+		return errors.New("boom")
+	})
+
+	out := buf.String()
+	if !strings.Contains(out, "msg=retrying") || !strings.Contains(out, "attempt=1") || !strings.Contains(out, "error=boom") {
+		t.Fatalf("missing retry log: %s", out)
+	}
+
+	if !strings.Contains(out, "msg=\"giving up\"") || !strings.Contains(out, "attempt=2") {
+		t.Fatalf("missing give up log: %s", out)
+	}
+}
@@ -3,7 +3,11 @@ package retrier
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"time"
+
+	"github.com/provincialig/golimitless/clock"
 )
 
 const (
@@ -15,62 +19,230 @@ var (
 	ErrMaxRetry       = errors.New("max retry")
 	ErrContextCancel  = errors.New("context cancel")
 	ErrContextTimeout = errors.New("context timeout")
+	ErrAttemptTimeout = errors.New("attempt timeout")
 )
 
 type Retrier interface {
 	Run(ctx context.Context, fn func() error) error
+	RunContext(ctx context.Context, fn func(ctx context.Context, attempt int) error) error
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+type Option func(r *myRetrier)
+
+func WithBackoff(backoff Backoff) Option {
+	return func(r *myRetrier) {
+		if backoff != nil {
+			r.backoff = backoff
+		}
+	}
+}
+
+func RetryableIf(fn func(err error) bool) Option {
+	return func(r *myRetrier) {
+		r.retryable = fn
+	}
+}
+
+func WithClock(c clock.Clock) Option {
+	return func(r *myRetrier) {
+		r.clock = clock.OrDefault(c)
+	}
+}
+
+// AttemptTimeout gives each attempt a context deadline. The deadline uses wall-clock time,
+// it is not driven by the Clock set with WithClock.
+func AttemptTimeout(d time.Duration) Option {
+	return func(r *myRetrier) {
+		r.attemptTimeout = d
+	}
 }
 
 type myRetrier struct {
-	delay    time.Duration
-	maxRetry int
+	clock clock.Clock
+
+	backoff   Backoff
+	retryable func(err error) bool
+	maxRetry  int
+	budget    Budget
+
+	attemptTimeout time.Duration
+	maxRetryAfter  time.Duration
+
+	onRetry   func(attempt int, err error, next time.Duration)
+	onGiveUp  func(attempt int, err error)
+	onSuccess func(attempt int, elapsed time.Duration)
+	logger    *slog.Logger
+}
+
+func (r myRetrier) attempt(ctx context.Context, retry int, fn func(ctx context.Context, attempt int) error) (bool, error) {
+	if r.attemptTimeout <= 0 {
+		return false, fn(ctx, retry)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, r.attemptTimeout)
+	defer cancel()
+
+	err := fn(attemptCtx, retry)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return true, fmt.Errorf("%w: %w", ErrAttemptTimeout, err)
+	}
+
+	return false, err
+}
+
+func (r myRetrier) classify(err error) (bool, error) {
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return false, perm.err
+	}
+
+	if r.retryable != nil && !r.retryable(err) {
+		return false, err
+	}
+
+	return true, err
+}
+
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return ErrContextCancel
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrContextTimeout
+	}
+	return ctx.Err()
 }
 
 func (r myRetrier) Run(ctx context.Context, fn func() error) error {
+	return r.RunContext(ctx, func(context.Context, int) error {
+		return fn()
+	})
+}
+
+func (r myRetrier) RunContext(ctx context.Context, fn func(ctx context.Context, attempt int) error) error {
 	retry := 0
+	delay := time.Duration(0)
+	start := r.clock.Now()
+	attempts := []AttemptError{}
+
+	if r.budget != nil {
+		r.budget.Request()
+	}
 
 	for {
 		if ctx.Err() != nil {
-			if errors.Is(ctx.Err(), context.Canceled) {
-				return ErrContextCancel
-			}
-			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-				return ErrContextTimeout
-			}
-			return ctx.Err()
+			return r.giveUp(ctx, retry, contextError(ctx))
+		}
+
+		retry++
+
+		attemptStart := r.clock.Now()
+
+		timedOut, err := r.attempt(ctx, retry, fn)
+		if err == nil {
+			r.succeeded(retry, r.clock.Now().Sub(start))
+			return nil
+		}
+
+		attempts = append(attempts, AttemptError{
+			Attempt:  retry,
+			Err:      err,
+			Start:    attemptStart,
+			Duration: r.clock.Now().Sub(attemptStart),
+		})
+
+		if ok, cause := r.classify(err); !ok && !timedOut {
+			return r.giveUp(ctx, retry, cause)
 		}
 
 		if r.maxRetry > 0 && retry >= r.maxRetry {
-			return ErrMaxRetry
+			return r.giveUp(ctx, retry, &RetryError{
+				Attempts: attempts,
+				Elapsed:  r.clock.Now().Sub(start),
+			})
 		}
 
-		retry++
+		if r.budget != nil && !r.budget.TryRetry() {
+			return r.giveUp(ctx, retry, fmt.Errorf("%w: %w", ErrBudgetExhausted, err))
+		}
 
-		if err := fn(); err != nil {
-			if r.delay == 0 {
-				continue
-			}
-
-			select {
-			case <-time.After(r.delay):
-			case <-ctx.Done():
-				if errors.Is(ctx.Err(), context.Canceled) {
-					return ErrContextCancel
-				}
-				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-					return ErrContextTimeout
-				}
-				return ctx.Err()
-			}
+		if hint, ok := r.retryAfter(err); ok {
+			delay = hint
 		} else {
-			return nil
+			delay = r.backoff.Next(retry, delay)
+		}
+
+		r.retrying(ctx, retry, err, delay)
+
+		if delay <= 0 {
+			continue
+		}
+
+		t := r.clock.NewTimer(delay)
+
+		select {
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return r.giveUp(ctx, retry, contextError(ctx))
 		}
 	}
 }
 
-func New(delay time.Duration, maxRetry int) Retrier {
-	return myRetrier{
-		delay:    delay,
+func Do[T any](ctx context.Context, r Retrier, fn func(ctx context.Context, attempt int) (T, error)) (T, error) {
+	var result T
+
+	err := r.RunContext(ctx, func(ctx context.Context, attempt int) error {
+		v, err := fn(ctx, attempt)
+		if err != nil {
+			return err
+		}
+
+		result = v
+		return nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result, nil
+}
+
+func New(delay time.Duration, maxRetry int, opts ...Option) Retrier {
+	r := myRetrier{
+		clock:    clock.New(),
+		backoff:  Constant(delay),
 		maxRetry: maxRetry,
 	}
+
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return r
 }
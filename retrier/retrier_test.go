@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/provincialig/golimitless/clock"
 	"github.com/provincialig/golimitless/retrier"
 )
 
@@ -74,3 +75,202 @@ func Test_ContextTimeout(t *testing.T) {
 		t.Fatalf("expected ErrContextTimeout, got %v", err)
 	}
 }
+
+func Test_WithBackoff(t *testing.T) {
+	attempts := []int{}
+
+	backoff := retrier.BackoffFunc(func(attempt int, prev time.Duration) time.Duration {
+		attempts = append(attempts, attempt)
+		return time.Millisecond
+	})
+
+	calls := 0
+
+	err := retrier.New(time.Hour, 4, retrier.WithBackoff(backoff)).Run(context.Background(), func() error {
+		calls++
+		return errors.New("fail")
+	})
+	if !errors.Is(err, retrier.ErrMaxRetry) {
+		t.Fatalf("expected ErrMaxRetry, got %v", err)
+	}
+
+	if calls != 4 {
+		t.Fatalf("expected 4 calls, got %d", calls)
+	}
+
+	if len(attempts) != 3 || attempts[0] != 1 || attempts[2] != 3 {
+		t.Fatalf("unexpected backoff attempts: %v", attempts)
+	}
+}
+
+func Test_PermanentError(t *testing.T) {
+	errValidation := errors.New("validation")
+	calls := 0
+
+	err := retrier.New(retrier.NO_DELAY, 10).Run(context.Background(), func() error {
+		calls++
+		return retrier.Permanent(errValidation)
+	})
+	if err != errValidation {
+		t.Fatalf("expected original error, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+
+	if retrier.Permanent(nil) != nil {
+		t.Fatal("expected nil for Permanent(nil)")
+	}
+}
+
+func Test_RetryableIf(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errFatal := errors.New("fatal")
+	calls := 0
+
+	r := retrier.New(retrier.NO_DELAY, 10, retrier.RetryableIf(func(err error) bool {
+		return errors.Is(err, errTemporary)
+	}))
+
+	err := r.Run(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errTemporary
+		}
+		return errFatal
+	})
+	if !errors.Is(err, errFatal) {
+		t.Fatalf("expected fatal error, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
+
+func Test_Do(t *testing.T) {
+	type ctxKey struct{}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	v, err := retrier.Do(ctx, retrier.New(retrier.NO_DELAY, 5), func(ctx context.Context, attempt int) (int, error) {
+		if ctx.Value(ctxKey{}) != "value" {
+			t.Fatal("expected parent context to be forwarded")
+		}
+
+		if attempt < 3 {
+			return attempt, errors.New("fail")
+		}
+		return attempt * 10, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v != 30 {
+		t.Fatalf("expected 30, got %d", v)
+	}
+}
+
+func Test_DoFail(t *testing.T) {
+	v, err := retrier.Do(context.Background(), retrier.New(retrier.NO_DELAY, 2), func(ctx context.Context, attempt int) (string, error) {
+		return "partial", errors.New("fail")
+	})
+	if !errors.Is(err, retrier.ErrMaxRetry) {
+		t.Fatalf("expected ErrMaxRetry, got %v", err)
+	}
+
+	if v != "" {
+		t.Fatalf("expected zero value, got %q", v)
+	}
+}
+
+func Test_AttemptTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	r := retrier.New(retrier.NO_DELAY, 5,
+		retrier.AttemptTimeout(20*time.Millisecond),
+		retrier.RetryableIf(func(err error) bool {
+			return !errors.Is(err, context.DeadlineExceeded)
+		}),
+	)
+
+	v, err := retrier.Do(ctx, r, func(ctx context.Context, attempt int) (int, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Fatal("expected attempt deadline")
+		}
+
+		if attempt < 3 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return attempt, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v != 3 {
+		t.Fatalf("expected success on attempt 3, got %d", v)
+	}
+}
+
+func Test_AttemptTimeoutMaxRetry(t *testing.T) {
+	r := retrier.New(retrier.NO_DELAY, 2, retrier.AttemptTimeout(10*time.Millisecond))
+
+	err := r.RunContext(context.Background(), func(ctx context.Context, attempt int) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, retrier.ErrMaxRetry) || !errors.Is(err, retrier.ErrAttemptTimeout) {
+		t.Fatalf("expected ErrMaxRetry with attempt timeouts, got %v", err)
+	}
+}
+
+func Test_AttemptTimeoutParentContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	r := retrier.New(retrier.NO_DELAY, retrier.NO_MAX_RETRY, retrier.AttemptTimeout(20*time.Millisecond))
+
+	err := r.RunContext(ctx, func(ctx context.Context, attempt int) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, retrier.ErrContextTimeout) {
+		t.Fatalf("expected ErrContextTimeout, got %v", err)
+	}
+}
+
+func Test_WithClock(t *testing.T) {
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	r := retrier.New(time.Hour, 3, retrier.WithClock(c))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Run(context.Background(), func() error {
+			return errors.New("fail")
+		})
+	}()
+
+	for range 2 {
+		c.BlockUntil(1)
+		c.Advance(time.Hour)
+	}
+
+	select {
+	case err := <-done:
+		var retryErr *retrier.RetryError
+		if !errors.As(err, &retryErr) {
+			t.Fatalf("expected RetryError, got %v", err)
+		}
+		if retryErr.Elapsed != 2*time.Hour {
+			t.Fatalf("expected 2h elapsed, got %v", retryErr.Elapsed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("retrier did not complete")
+	}
+}
@@ -0,0 +1,50 @@
+package retrier
+
+import (
+	"errors"
+	"time"
+)
+
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+func (e *retryAfterError) RetryAfter() time.Duration {
+	return e.after
+}
+
+func RetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, after: d}
+}
+
+func MaxRetryAfter(d time.Duration) Option {
+	return func(r *myRetrier) {
+		r.maxRetryAfter = d
+	}
+}
+
+func (r myRetrier) retryAfter(err error) (time.Duration, bool) {
+	var hint RetryAfterError
+	if !errors.As(err, &hint) {
+		return 0, false
+	}
+
+	return capDelay(hint.RetryAfter(), r.maxRetryAfter), true
+}
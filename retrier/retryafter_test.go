@@ -0,0 +1,73 @@
+package retrier_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/provincialig/golimitless/retrier"
+)
+
+type throttledError struct {
+	after time.Duration
+}
+
+func (e throttledError) Error() string {
+	return "throttled"
+}
+
+func (e throttledError) RetryAfter() time.Duration {
+	return e.after
+}
+
+func Test_RetryAfterOverridesBackoff(t *testing.T) {
+	delays := []time.Duration{}
+
+	r := retrier.New(time.Hour, 3, retrier.OnRetry(func(attempt int, err error, next time.Duration) {
+		delays = append(delays, next)
+	}))
+
+	calls := 0
+	err := r.Run(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return retrier.RetryAfter(errors.New("busy"), time.Millisecond)
+		}
+		if calls == 2 {
+			return throttledError{after: 2 * time.Millisecond}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(delays) != 2 || delays[0] != time.Millisecond || delays[1] != 2*time.Millisecond {
+		t.Fatalf("unexpected delays: %v", delays)
+	}
+}
+
+func Test_MaxRetryAfter(t *testing.T) {
+	delays := []time.Duration{}
+
+	r := retrier.New(retrier.NO_DELAY, 2,
+		retrier.MaxRetryAfter(5*time.Millisecond),
+		retrier.OnRetry(func(attempt int, err error, next time.Duration) {
+			delays = append(delays, next)
+		}),
+	)
+
+	errBusy := errors.New("busy")
+
+	err := r.Run(context.Background(), func() error {
+		return retrier.RetryAfter(errBusy, time.Hour)
+	})
+	if !errors.Is(err, errBusy) {
+		t.Fatalf("expected wrapped error, got %v", err)
+	}
+
+	if len(delays) != 1 || delays[0] != 5*time.Millisecond {
+		t.Fatalf("expected clamped delay, got %v", delays)
+	}
+}
@@ -0,0 +1,138 @@
+package stack
+
+type AggregateStack[T, A any] interface {
+	Stack[T]
+	Aggregate() (A, bool)
+}
+
+type MinMaxStack[T any] interface {
+	Stack[T]
+	Min() (T, bool)
+	Max() (T, bool)
+}
+
+// Every level stores the aggregate of itself and everything below it.
+type level[T, A any] struct {
+	value T
+	agg   A
+}
+
+type aggregateSlice[T, A any] struct {
+	levels  []level[T, A]
+	lift    func(T) A
+	combine func(A, A) A
+}
+
+func (s *aggregateSlice[T, A]) push(value T) {
+	agg := s.lift(value)
+	if len(s.levels) > 0 {
+		agg = s.combine(s.levels[len(s.levels)-1].agg, agg)
+	}
+
+	s.levels = append(s.levels, level[T, A]{value: value, agg: agg})
+}
+
+func (s *aggregateSlice[T, A]) pop() (T, bool) {
+	if len(s.levels) == 0 {
+		var zero T
+		return zero, false
+	}
+
+	last := len(s.levels) - 1
+	el := s.levels[last]
+	s.levels[last] = level[T, A]{}
+	s.levels = s.levels[:last]
+
+	return el.value, true
+}
+
+func (s *aggregateSlice[T, A]) peek() (T, bool) {
+	if len(s.levels) == 0 {
+		var zero T
+		return zero, false
+	}
+	return s.levels[len(s.levels)-1].value, true
+}
+
+func (s *aggregateSlice[T, A]) aggregate() (A, bool) {
+	if len(s.levels) == 0 {
+		var zero A
+		return zero, false
+	}
+	return s.levels[len(s.levels)-1].agg, true
+}
+
+func (s *aggregateSlice[T, A]) values(n int) []T {
+	values := make([]T, 0, max(min(n, len(s.levels)), 0))
+	for i := len(s.levels) - 1; i >= 0 && len(values) < n; i-- {
+		values = append(values, s.levels[i].value)
+	}
+	return values
+}
+
+func (s *aggregateSlice[T, A]) len() int {
+	return len(s.levels)
+}
+
+func (s *aggregateSlice[T, A]) clear() {
+	clear(s.levels)
+	s.levels = s.levels[:0]
+}
+
+type aggregateStack[T, A any] struct {
+	*lockedStack[T]
+	levels *aggregateSlice[T, A]
+}
+
+func (s aggregateStack[T, A]) Aggregate() (A, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.levels.aggregate()
+}
+
+// NewWithAggregate keeps combine(below, lift(value)) for every level, combine must be associative.
+func NewWithAggregate[T, A any](lift func(T) A, combine func(A, A) A) AggregateStack[T, A] {
+	levels := &aggregateSlice[T, A]{lift: lift, combine: combine}
+	return aggregateStack[T, A]{
+		lockedStack: newLockedStack[T](levels, 0),
+		levels:      levels,
+	}
+}
+
+type bounds[T any] struct {
+	min T
+	max T
+}
+
+type minMaxStack[T any] struct {
+	AggregateStack[T, bounds[T]]
+}
+
+func (s minMaxStack[T]) Min() (T, bool) {
+	b, ok := s.Aggregate()
+	return b.min, ok
+}
+
+func (s minMaxStack[T]) Max() (T, bool) {
+	b, ok := s.Aggregate()
+	return b.max, ok
+}
+
+func NewMinMax[T any](less func(a, b T) bool) MinMaxStack[T] {
+	lift := func(value T) bounds[T] {
+		return bounds[T]{min: value, max: value}
+	}
+
+	combine := func(below, top bounds[T]) bounds[T] {
+		if less(top.min, below.min) {
+			below.min = top.min
+		}
+		if less(below.max, top.max) {
+			below.max = top.max
+		}
+		return below
+	}
+
+	return minMaxStack[T]{NewWithAggregate(lift, combine)}
+}
@@ -0,0 +1,76 @@
+package stack_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/provincialig/golimitless/stack"
+)
+
+func Test_MinMax(t *testing.T) {
+	s := stack.NewMinMax(func(a, b int) bool { return a < b })
+
+	if _, ok := s.Min(); ok {
+		t.Fatal("Expected no minimum on an empty stack")
+	}
+
+	s.PushAll(5, 3, 8, 1, 9)
+
+	expected := []struct{ min, max int }{
+		{1, 9},
+		{1, 8},
+		{3, 8},
+		{3, 5},
+		{5, 5},
+	}
+
+	for _, e := range expected {
+		if m, ok := s.Min(); !ok || m != e.min {
+			t.Fatalf("Min: %d, expected %d", m, e.min)
+		}
+		if m, ok := s.Max(); !ok || m != e.max {
+			t.Fatalf("Max: %d, expected %d", m, e.max)
+		}
+		if _, err := s.Pop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, ok := s.Max(); ok {
+		t.Fatal("Expected no maximum on an empty stack")
+	}
+}
+
+func Test_Aggregate_Sum(t *testing.T) {
+	s := stack.NewWithAggregate(
+		func(v int) int { return v },
+		func(a, b int) int { return a + b },
+	)
+
+	s.PushAll(1, 2, 3)
+	s.Push(4)
+
+	if sum, ok := s.Aggregate(); !ok || sum != 10 {
+		t.Fatalf("Aggregate: %d", sum)
+	}
+
+	if _, ok := s.TryPopN(2); !ok {
+		t.Fatal("Expected TryPopN to succeed")
+	}
+
+	if sum, ok := s.Aggregate(); !ok || sum != 3 {
+		t.Fatalf("Aggregate: %d", sum)
+	}
+
+	s.Clear()
+
+	if _, ok := s.Aggregate(); ok {
+		t.Fatal("Expected no aggregate on an empty stack")
+	}
+
+	s.Push(7)
+
+	if sum, ok := s.Aggregate(); !ok || sum != 7 {
+		t.Fatalf("Aggregate: %d", sum)
+	}
+}
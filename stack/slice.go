@@ -0,0 +1,53 @@
+package stack
+
+type slice[T any] struct {
+	items []T
+}
+
+func (s *slice[T]) push(value T) {
+	s.items = append(s.items, value)
+}
+
+func (s *slice[T]) pop() (T, bool) {
+	var zero T
+
+	if len(s.items) == 0 {
+		return zero, false
+	}
+
+	last := len(s.items) - 1
+	value := s.items[last]
+	s.items[last] = zero
+	s.items = s.items[:last]
+
+	return value, true
+}
+
+func (s *slice[T]) peek() (T, bool) {
+	if len(s.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return s.items[len(s.items)-1], true
+}
+
+func (s *slice[T]) values(n int) []T {
+	values := make([]T, 0, max(min(n, len(s.items)), 0))
+	for i := len(s.items) - 1; i >= 0 && len(values) < n; i-- {
+		values = append(values, s.items[i])
+	}
+	return values
+}
+
+func (s *slice[T]) len() int {
+	return len(s.items)
+}
+
+func (s *slice[T]) clear() {
+	clear(s.items)
+	s.items = s.items[:0]
+}
+
+func newSlice[T any](capacity int) *slice[T] {
+	return &slice[T]{items: make([]T, 0, max(capacity, 0))}
+}
@@ -10,195 +10,477 @@ import (
 var (
 	ErrTimeout  = errors.New("context timeout")
 	ErrCanceled = errors.New("context canceled")
+	ErrCapacity = errors.New("requested more elements than the capacity")
 )
 
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return ErrCanceled
+}
+
 type Stack[T any] interface {
 	Push(value T)
+	PushAll(values ...T)
 	TryPop() (T, bool)
 	Pop(ctx context.Context) (T, error)
+	TryPopN(n int) ([]T, bool)
+	PopN(ctx context.Context, n int) ([]T, error)
 	TryPeek() (T, bool)
 	Peek(ctx context.Context) (T, error)
+	PeekN(n int) []T
+	Snapshot() []T
+	Drain() []T
 	Clear()
 	IsEmpty() bool
 	Size() int
 }
 
+type BoundedStack[T any] interface {
+	Push(ctx context.Context, value T) error
+	TryPush(value T) bool
+	TryPop() (T, bool)
+	Pop(ctx context.Context) (T, error)
+	TryPopN(n int) ([]T, bool)
+	PopN(ctx context.Context, n int) ([]T, error)
+	TryPeek() (T, bool)
+	Peek(ctx context.Context) (T, error)
+	PeekN(n int) []T
+	Snapshot() []T
+	Drain() []T
+	Clear()
+	IsEmpty() bool
+	Size() int
+	Cap() int
+}
+
+type storage[T any] interface {
+	push(value T)
+	pop() (T, bool)
+	peek() (T, bool)
+	values(n int) []T
+	len() int
+	clear()
+}
+
 type node[T any] struct {
 	value T
 	next  *node[T]
 }
 
-type linkedListStack[T any] struct {
-	mut  *sync.Mutex
-	cond *sync.Cond
-
-	top *node[T]
-
-	size int64
+type linkedList[T any] struct {
+	top  *node[T]
+	size int
 }
 
-func (s *linkedListStack[T]) Push(value T) {
-	s.mut.Lock()
-	defer s.mut.Unlock()
-
-	newVal := &node[T]{value: value}
+func (l *linkedList[T]) push(value T) {
+	l.top = &node[T]{value: value, next: l.top}
+	l.size++
+}
 
-	if s.top == nil {
-		s.top = newVal
-	} else {
-		newVal.next = s.top
-		s.top = newVal
+func (l *linkedList[T]) pop() (T, bool) {
+	if l.top == nil {
+		var zero T
+		return zero, false
 	}
 
-	atomic.AddInt64(&s.size, 1)
+	el := l.top
+	l.top = el.next
+	el.next = nil
+	l.size--
+
+	return el.value, true
 }
 
-func (s *linkedListStack[T]) popUnsafe() (T, bool) {
-	if s.top == nil {
+func (l *linkedList[T]) peek() (T, bool) {
+	if l.top == nil {
 		var zero T
 		return zero, false
 	}
+	return l.top.value, true
+}
 
-	el := s.top
-	s.top = el.next
-	el.next = nil
+func (l *linkedList[T]) values(n int) []T {
+	values := make([]T, 0, max(min(n, l.size), 0))
+	for el := l.top; el != nil && len(values) < n; el = el.next {
+		values = append(values, el.value)
+	}
+	return values
+}
 
-	atomic.AddInt64(&s.size, -1)
+func (l *linkedList[T]) len() int {
+	return l.size
+}
 
-	return el.value, true
+func (l *linkedList[T]) clear() {
+	l.top = nil
+	l.size = 0
 }
 
-func (s *linkedListStack[T]) TryPop() (T, bool) {
-	s.mut.Lock()
-	defer s.mut.Unlock()
+type Option func(c *config)
 
-	return s.popUnsafe()
+type implementation int
+
+const (
+	implLinkedList implementation = iota
+	implSlice
+	implTreiber
+)
+
+type config struct {
+	impl     implementation
+	capacity int
 }
 
-func (s *linkedListStack[T]) Pop(ctx context.Context) (T, error) {
-	if ctx.Err() != nil {
-		var zero T
-		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return zero, ErrTimeout
-		}
-		return zero, ErrCanceled
+func WithLinkedList() Option {
+	return func(c *config) {
+		c.impl = implLinkedList
 	}
+}
 
-	s.mut.Lock()
-	defer s.mut.Unlock()
+// WithSlice stores elements in a growable slice, preallocating capacity elements.
+func WithSlice(capacity int) Option {
+	return func(c *config) {
+		c.impl = implSlice
+		c.capacity = capacity
+	}
+}
 
+// WithTreiber uses a lock-free stack, blocking calls are woken through a channel.
+func WithTreiber() Option {
+	return func(c *config) {
+		c.impl = implTreiber
+	}
+}
+
+type lockedStack[T any] struct {
+	mut     *sync.Mutex
+	cond    *sync.Cond
+	notFull *sync.Cond
+
+	items storage[T]
+
+	capacity int
+
+	// Waiters that need every push to wake them, so pushes Broadcast instead of Signal
+	broadcastWaiters int
+
+	size int64
+}
+
+func (s *lockedStack[T]) wakeOnDone(ctx context.Context) func() {
 	stop := make(chan struct{})
-	defer close(stop)
 
 	go func() {
 		select {
 		case <-ctx.Done():
 			s.mut.Lock()
 			s.cond.Broadcast()
+			s.notFull.Broadcast()
 			s.mut.Unlock()
 		case <-stop:
 			return
 		}
 	}()
 
-	for {
-		if item, ok := s.popUnsafe(); ok {
-			return item, nil
-		}
+	return func() { close(stop) }
+}
 
-		if err := ctx.Err(); err != nil {
-			var zero T
-			if errors.Is(err, context.DeadlineExceeded) {
-				return zero, ErrTimeout
-			}
-			return zero, ErrCanceled
+func (s *lockedStack[T]) pushUnsafe(value T) {
+	s.items.push(value)
+
+	atomic.AddInt64(&s.size, 1)
+
+	if s.broadcastWaiters > 0 {
+		s.cond.Broadcast()
+	} else {
+		s.cond.Signal()
+	}
+}
+
+func (s *lockedStack[T]) fullUnsafe() bool {
+	return s.capacity > 0 && int(s.size) >= s.capacity
+}
+
+func (s *lockedStack[T]) Push(value T) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.pushUnsafe(value)
+}
+
+func (s *lockedStack[T]) PushAll(values ...T) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for _, value := range values {
+		s.pushUnsafe(value)
+	}
+}
+
+func (s *lockedStack[T]) popUnsafe() (T, bool) {
+	value, ok := s.items.pop()
+	if !ok {
+		return value, false
+	}
+
+	atomic.AddInt64(&s.size, -1)
+
+	if s.capacity > 0 {
+		s.notFull.Signal()
+	}
+
+	return value, true
+}
+
+func (s *lockedStack[T]) TryPop() (T, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.popUnsafe()
+}
+
+func (s *lockedStack[T]) popNUnsafe(n int) []T {
+	values := make([]T, 0, n)
+	for range n {
+		value, _ := s.popUnsafe()
+		values = append(values, value)
+	}
+	return values
+}
+
+func (s *lockedStack[T]) TryPopN(n int) ([]T, bool) {
+	if n <= 0 {
+		return nil, true
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if int(s.size) < n {
+		return nil, false
+	}
+
+	return s.popNUnsafe(n), true
+}
+
+func (s *lockedStack[T]) waitUnsafe(ctx context.Context, n int) error {
+	if int(s.size) >= n {
+		return nil
+	}
+
+	stop := s.wakeOnDone(ctx)
+	defer stop()
+
+	for int(s.size) < n {
+		if ctx.Err() != nil {
+			return contextError(ctx)
 		}
 
 		s.cond.Wait()
 	}
+
+	return nil
 }
 
-func (s *linkedListStack[T]) peekUnsafe() (T, bool) {
-	if s.top == nil {
+func (s *lockedStack[T]) Pop(ctx context.Context) (T, error) {
+	if ctx.Err() != nil {
 		var zero T
-		return zero, false
+		return zero, contextError(ctx)
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if err := s.waitUnsafe(ctx, 1); err != nil {
+		var zero T
+		return zero, err
 	}
 
-	return s.top.value, true
+	item, _ := s.popUnsafe()
+	return item, nil
 }
 
-func (s *linkedListStack[T]) TryPeek() (T, bool) {
+func (s *lockedStack[T]) PopN(ctx context.Context, n int) ([]T, error) {
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+
+	if n <= 0 {
+		return nil, nil
+	}
+
+	// A bounded stack can never hold more than its capacity
+	if s.capacity > 0 && n > s.capacity {
+		return nil, ErrCapacity
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	// A single Signal may wake another waiter and leave PopN asleep once n items are there
+	s.broadcastWaiters++
+	defer func() { s.broadcastWaiters-- }()
+
+	if err := s.waitUnsafe(ctx, n); err != nil {
+		return nil, err
+	}
+
+	return s.popNUnsafe(n), nil
+}
+
+func (s *lockedStack[T]) TryPeek() (T, bool) {
 	s.mut.Lock()
 	defer s.mut.Unlock()
 
-	return s.peekUnsafe()
+	return s.items.peek()
 }
 
-func (s *linkedListStack[T]) Peek(ctx context.Context) (T, error) {
+func (s *lockedStack[T]) Peek(ctx context.Context) (T, error) {
 	if ctx.Err() != nil {
 		var zero T
-		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return zero, ErrTimeout
-		}
-		return zero, ErrCanceled
+		return zero, contextError(ctx)
 	}
 
 	s.mut.Lock()
 	defer s.mut.Unlock()
 
-	ctxDone := make(chan struct{})
+	// Peek does not consume the item, so it must not take the Signal of a Pop
+	s.broadcastWaiters++
+	defer func() { s.broadcastWaiters-- }()
 
-	go func() {
-		<-ctx.Done()
+	if err := s.waitUnsafe(ctx, 1); err != nil {
+		var zero T
+		return zero, err
+	}
 
-		close(ctxDone)
+	item, _ := s.items.peek()
+	return item, nil
+}
 
-		s.mut.Lock()
-		s.cond.Broadcast()
-		s.mut.Unlock()
-	}()
+func (s *lockedStack[T]) PeekN(n int) []T {
+	s.mut.Lock()
+	defer s.mut.Unlock()
 
-	for {
-		if item, ok := s.peekUnsafe(); ok {
-			return item, nil
-		}
+	return s.items.values(n)
+}
 
-		select {
-		case <-ctxDone:
-			var zero T
-			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-				return zero, ErrTimeout
-			}
-			return zero, ErrCanceled
-		default:
-		}
+func (s *lockedStack[T]) Snapshot() []T {
+	s.mut.Lock()
+	defer s.mut.Unlock()
 
-		s.cond.Wait()
-	}
+	return s.items.values(int(s.size))
 }
 
-func (s *linkedListStack[T]) Clear() {
+func (s *lockedStack[T]) Drain() []T {
 	s.mut.Lock()
 	defer s.mut.Unlock()
 
-	s.top = nil
+	values := s.items.values(int(s.size))
+
+	s.items.clear()
+	atomic.StoreInt64(&s.size, 0)
+
+	s.notFull.Broadcast()
+
+	return values
+}
+
+func (s *lockedStack[T]) Clear() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.items.clear()
 	atomic.StoreInt64(&s.size, 0)
 
 	s.cond.Broadcast()
+	s.notFull.Broadcast()
 }
 
-func (s *linkedListStack[T]) Size() int {
+func (s *lockedStack[T]) Size() int {
 	return int(atomic.LoadInt64(&s.size))
 }
 
-func (s *linkedListStack[T]) IsEmpty() bool {
+func (s *lockedStack[T]) IsEmpty() bool {
 	return atomic.LoadInt64(&s.size) == 0
 }
 
-func New[T any]() Stack[T] {
+type boundedStack[T any] struct {
+	*lockedStack[T]
+}
+
+func (s boundedStack[T]) Push(ctx context.Context, value T) error {
+	if ctx.Err() != nil {
+		return contextError(ctx)
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.fullUnsafe() {
+		stop := s.wakeOnDone(ctx)
+		defer stop()
+
+		for s.fullUnsafe() {
+			if ctx.Err() != nil {
+				return contextError(ctx)
+			}
+
+			s.notFull.Wait()
+		}
+	}
+
+	s.pushUnsafe(value)
+
+	return nil
+}
+
+func (s boundedStack[T]) TryPush(value T) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.fullUnsafe() {
+		return false
+	}
+
+	s.pushUnsafe(value)
+
+	return true
+}
+
+func (s boundedStack[T]) Cap() int {
+	return s.capacity
+}
+
+func newLockedStack[T any](items storage[T], capacity int) *lockedStack[T] {
 	var mut sync.Mutex
-	return &linkedListStack[T]{
-		mut:  &mut,
-		cond: sync.NewCond(&mut),
+	return &lockedStack[T]{
+		mut:      &mut,
+		cond:     sync.NewCond(&mut),
+		notFull:  sync.NewCond(&mut),
+		items:    items,
+		capacity: capacity,
+	}
+}
+
+func New[T any](opts ...Option) Stack[T] {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	switch c.impl {
+	case implSlice:
+		return newLockedStack[T](newSlice[T](c.capacity), 0)
+	case implTreiber:
+		return newTreiber[T]()
+	default:
+		return newLockedStack[T](&linkedList[T]{}, 0)
 	}
 }
+
+func NewBounded[T any](capacity int) BoundedStack[T] {
+	capacity = max(capacity, 1)
+	return boundedStack[T]{newLockedStack[T](newSlice[T](capacity), capacity)}
+}
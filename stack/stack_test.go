@@ -3,6 +3,8 @@ package stack_test
 import (
 	"context"
 	"errors"
+	"runtime"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -250,3 +252,515 @@ func Test_TryPeek_Empty_Multithread(t *testing.T) {
 		t.Fatalf("Expected not ok, got ok with value %d", val)
 	}
 }
+
+func Test_Pop_WakesOnPush(t *testing.T) {
+	s := stack.New[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.Push(1)
+	}()
+
+	start := time.Now()
+	val, err := s.Pop(ctx)
+	if err != nil || val != 1 {
+		t.Fatalf("Expected 1, got %d %v", val, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Pop was not woken up by Push: %v", elapsed)
+	}
+}
+
+func Test_PeekAndPop_WakeOnPush(t *testing.T) {
+	s := stack.New[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	peeked := make(chan int)
+	popped := make(chan int)
+
+	go func() {
+		val, _ := s.Peek(ctx)
+		peeked <- val
+	}()
+	go func() {
+		val, _ := s.Pop(ctx)
+		popped <- val
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	s.Push(1)
+
+	select {
+	case val := <-popped:
+		if val != 1 {
+			t.Fatalf("Expected 1, got %d", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Pop was not woken up by Push")
+	}
+
+	s.Push(2)
+
+	select {
+	case <-peeked:
+	case <-time.After(time.Second):
+		t.Fatal("Peek was not woken up by Push")
+	}
+}
+
+func Test_Peek_NoGoroutineLeak(t *testing.T) {
+	s := stack.New[int]()
+	s.Push(1)
+
+	before := runtime.NumGoroutine()
+
+	for range 100 {
+		ctx, cancel := context.WithCancel(context.Background())
+		if _, err := s.Peek(ctx); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+	}
+
+	s.Clear()
+
+	for range 100 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		_, _ = s.Peek(ctx)
+		cancel()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("Goroutine leak: %d before, %d after", before, after)
+	}
+}
+
+func Test_Bounded_Push(t *testing.T) {
+	s := stack.NewBounded[int](2)
+
+	if s.Cap() != 2 {
+		t.Fatalf("Cap: %d", s.Cap())
+	}
+
+	if !s.TryPush(1) || !s.TryPush(2) {
+		t.Fatal("Expected TryPush to succeed")
+	}
+
+	if s.TryPush(3) {
+		t.Fatal("Expected TryPush to fail on a full stack")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := s.Push(ctx, 3); !errors.Is(err, stack.ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+
+	if size := s.Size(); size != 2 {
+		t.Fatalf("Size: %d", size)
+	}
+}
+
+func Test_Bounded_Push_Blocking(t *testing.T) {
+	s := stack.NewBounded[int](1)
+	if err := s.Push(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- s.Push(context.Background(), 2)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected Push to block on a full stack")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if el, ok := s.TryPop(); !ok || el != 1 {
+		t.Fatalf("Element: %d", el)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if el, ok := s.TryPeek(); !ok || el != 2 {
+		t.Fatalf("Element: %d", el)
+	}
+}
+
+func Test_Bounded_ProducerConsumer(t *testing.T) {
+	s := stack.NewBounded[int](3)
+	const n = 200
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := range n {
+			if err := s.Push(context.Background(), i); err != nil {
+				t.Errorf("Push error: %v", err)
+			}
+		}
+	}()
+
+	received := 0
+	go func() {
+		defer wg.Done()
+		for range n {
+			if _, err := s.Pop(context.Background()); err != nil {
+				t.Errorf("Pop error: %v", err)
+			}
+			received++
+		}
+	}()
+
+	wg.Wait()
+
+	if received != n || !s.IsEmpty() {
+		t.Fatalf("Received %d, size %d", received, s.Size())
+	}
+}
+
+func Test_PushAll_PeekN_Snapshot(t *testing.T) {
+	s := stack.New[int]()
+	s.PushAll(1, 2, 3, 4)
+
+	if size := s.Size(); size != 4 {
+		t.Fatalf("Size: %d", size)
+	}
+
+	if top := s.PeekN(2); !slices.Equal(top, []int{4, 3}) {
+		t.Fatalf("PeekN: %v", top)
+	}
+
+	if top := s.PeekN(10); !slices.Equal(top, []int{4, 3, 2, 1}) {
+		t.Fatalf("PeekN: %v", top)
+	}
+
+	if snap := s.Snapshot(); !slices.Equal(snap, []int{4, 3, 2, 1}) {
+		t.Fatalf("Snapshot: %v", snap)
+	}
+
+	if size := s.Size(); size != 4 {
+		t.Fatalf("Size after Snapshot: %d", size)
+	}
+}
+
+func Test_TryPopN(t *testing.T) {
+	s := stack.New[int]()
+	s.PushAll(1, 2, 3)
+
+	if _, ok := s.TryPopN(4); ok {
+		t.Fatal("Expected TryPopN to fail with too few elements")
+	}
+
+	if size := s.Size(); size != 3 {
+		t.Fatalf("Size: %d", size)
+	}
+
+	values, ok := s.TryPopN(2)
+	if !ok || !slices.Equal(values, []int{3, 2}) {
+		t.Fatalf("TryPopN: %v %v", values, ok)
+	}
+
+	if el, ok := s.TryPeek(); !ok || el != 1 {
+		t.Fatalf("Element: %d", el)
+	}
+}
+
+func Test_PopN_Blocking(t *testing.T) {
+	s := stack.New[int]()
+	s.Push(1)
+
+	done := make(chan []int)
+	go func() {
+		values, err := s.PopN(context.Background(), 3)
+		if err != nil {
+			t.Errorf("PopN error: %v", err)
+		}
+		done <- values
+	}()
+
+	s.Push(2)
+
+	select {
+	case <-done:
+		t.Fatal("Expected PopN to wait for enough elements")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.Push(3)
+
+	if values := <-done; !slices.Equal(values, []int{3, 2, 1}) {
+		t.Fatalf("PopN: %v", values)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := s.PopN(ctx, 1); !errors.Is(err, stack.ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+}
+
+func Test_Drain(t *testing.T) {
+	s := stack.NewBounded[int](3)
+	if err := s.Push(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Push(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Push(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if values := s.Drain(); !slices.Equal(values, []int{3, 2, 1}) {
+		t.Fatalf("Drain: %v", values)
+	}
+
+	if !s.IsEmpty() {
+		t.Fatalf("Size: %d", s.Size())
+	}
+
+	if !s.TryPush(4) {
+		t.Fatal("Expected TryPush to succeed after Drain")
+	}
+}
+
+var implementations = map[string]func() stack.Stack[int]{
+	"LinkedList": func() stack.Stack[int] { return stack.New[int](stack.WithLinkedList()) },
+	"Slice":      func() stack.Stack[int] { return stack.New[int](stack.WithSlice(16)) },
+	"Treiber":    func() stack.Stack[int] { return stack.New[int](stack.WithTreiber()) },
+}
+
+func Test_Implementations(t *testing.T) {
+	for name, newStack := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newStack()
+
+			if _, ok := s.TryPop(); ok {
+				t.Fatal("Expected TryPop to fail on an empty stack")
+			}
+
+			s.Push(1)
+			s.PushAll(2, 3, 4)
+
+			if size := s.Size(); size != 4 {
+				t.Fatalf("Size: %d", size)
+			}
+
+			if el, ok := s.TryPeek(); !ok || el != 4 {
+				t.Fatalf("TryPeek: %d", el)
+			}
+
+			if top := s.PeekN(2); !slices.Equal(top, []int{4, 3}) {
+				t.Fatalf("PeekN: %v", top)
+			}
+
+			if _, ok := s.TryPopN(5); ok {
+				t.Fatal("Expected TryPopN to fail with too few elements")
+			}
+
+			if values, ok := s.TryPopN(2); !ok || !slices.Equal(values, []int{4, 3}) {
+				t.Fatalf("TryPopN: %v", values)
+			}
+
+			if el, err := s.Pop(context.Background()); err != nil || el != 2 {
+				t.Fatalf("Pop: %d %v", el, err)
+			}
+
+			if snap := s.Snapshot(); !slices.Equal(snap, []int{1}) {
+				t.Fatalf("Snapshot: %v", snap)
+			}
+
+			s.PushAll(5, 6)
+
+			if values := s.Drain(); !slices.Equal(values, []int{6, 5, 1}) {
+				t.Fatalf("Drain: %v", values)
+			}
+
+			if !s.IsEmpty() {
+				t.Fatalf("Size: %d", s.Size())
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			if _, err := s.Peek(ctx); !errors.Is(err, stack.ErrTimeout) {
+				t.Fatalf("Expected ErrTimeout, got %v", err)
+			}
+		})
+	}
+}
+
+func Test_Implementations_Blocking(t *testing.T) {
+	for name, newStack := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newStack()
+
+			popped := make(chan []int)
+			go func() {
+				values, err := s.PopN(context.Background(), 2)
+				if err != nil {
+					t.Errorf("PopN error: %v", err)
+				}
+				popped <- values
+			}()
+
+			peeked := make(chan int)
+			go func() {
+				el, err := s.Peek(context.Background())
+				if err != nil {
+					t.Errorf("Peek error: %v", err)
+				}
+				peeked <- el
+			}()
+
+			time.Sleep(20 * time.Millisecond)
+			s.Push(1)
+
+			if el := <-peeked; el != 1 {
+				t.Fatalf("Peek: %d", el)
+			}
+
+			s.Push(2)
+
+			if values := <-popped; !slices.Equal(values, []int{2, 1}) {
+				t.Fatalf("PopN: %v", values)
+			}
+		})
+	}
+}
+
+func Test_Implementations_Concurrent(t *testing.T) {
+	for name, newStack := range implementations {
+		t.Run(name, func(t *testing.T) {
+			s := newStack()
+			const workers, n = 8, 500
+
+			var wg sync.WaitGroup
+			var mut sync.Mutex
+			seen := make(map[int]bool)
+
+			for w := range workers {
+				wg.Add(2)
+
+				go func() {
+					defer wg.Done()
+					for i := range n {
+						s.Push(w*n + i)
+					}
+				}()
+
+				go func() {
+					defer wg.Done()
+					for range n {
+						el, err := s.Pop(context.Background())
+						if err != nil {
+							t.Errorf("Pop error: %v", err)
+							return
+						}
+
+						mut.Lock()
+						seen[el] = true
+						mut.Unlock()
+					}
+				}()
+			}
+
+			wg.Wait()
+
+			if len(seen) != workers*n || !s.IsEmpty() {
+				t.Fatalf("Seen %d, size %d", len(seen), s.Size())
+			}
+		})
+	}
+}
+
+func benchmarkStack(b *testing.B, newStack func() stack.Stack[int]) {
+	b.Run("Sequential", func(b *testing.B) {
+		s := newStack()
+		for i := range b.N {
+			s.Push(i)
+			s.TryPop()
+		}
+	})
+
+	b.Run("Parallel", func(b *testing.B) {
+		s := newStack()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				s.Push(i)
+				s.TryPop()
+				i++
+			}
+		})
+	})
+
+	b.Run("ParallelBulk", func(b *testing.B) {
+		s := newStack()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				s.PushAll(1, 2, 3, 4)
+				s.TryPopN(4)
+			}
+		})
+	})
+}
+
+func Benchmark_StackLinkedList(b *testing.B) {
+	benchmarkStack(b, implementations["LinkedList"])
+}
+
+func Benchmark_StackSlice(b *testing.B) {
+	benchmarkStack(b, implementations["Slice"])
+}
+
+func Benchmark_StackTreiber(b *testing.B) {
+	benchmarkStack(b, implementations["Treiber"])
+}
+
+func Test_Bounded_PopN_OverCapacity(t *testing.T) {
+	s := stack.NewBounded[int](2)
+	if err := s.Push(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Push(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := s.PopN(ctx, 3); !errors.Is(err, stack.ErrCapacity) {
+		t.Fatalf("Expected ErrCapacity, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("Expected PopN to fail immediately, took %v", elapsed)
+	}
+
+	if _, ok := s.TryPopN(3); ok {
+		t.Fatal("Expected TryPopN to fail")
+	}
+
+	if size := s.Size(); size != 2 {
+		t.Fatalf("Size: %d", size)
+	}
+}
@@ -0,0 +1,242 @@
+package stack
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Nodes are immutable once published and never reused, so a CAS on top
+// cannot succeed against a recycled node (no ABA).
+type treiberNode[T any] struct {
+	value T
+	next  *treiberNode[T]
+	size  int
+}
+
+type treiberStack[T any] struct {
+	top atomic.Pointer[treiberNode[T]]
+
+	mut     sync.Mutex
+	wake    chan struct{}
+	waiters int64
+}
+
+func (s *treiberStack[T]) notify() {
+	if atomic.LoadInt64(&s.waiters) == 0 {
+		return
+	}
+
+	s.mut.Lock()
+	close(s.wake)
+	s.wake = make(chan struct{})
+	s.mut.Unlock()
+}
+
+func (s *treiberStack[T]) wait(ctx context.Context, n int) error {
+	atomic.AddInt64(&s.waiters, 1)
+	defer atomic.AddInt64(&s.waiters, -1)
+
+	for {
+		s.mut.Lock()
+		wake := s.wake
+		s.mut.Unlock()
+
+		if s.Size() >= n {
+			return nil
+		}
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return contextError(ctx)
+		}
+	}
+}
+
+func (s *treiberStack[T]) Push(value T) {
+	el := &treiberNode[T]{value: value}
+
+	for {
+		top := s.top.Load()
+		el.next = top
+		el.size = nodeSize(top) + 1
+
+		if s.top.CompareAndSwap(top, el) {
+			break
+		}
+	}
+
+	s.notify()
+}
+
+func (s *treiberStack[T]) PushAll(values ...T) {
+	if len(values) == 0 {
+		return
+	}
+
+	nodes := make([]treiberNode[T], len(values))
+	for i := 1; i < len(nodes); i++ {
+		nodes[i].next = &nodes[i-1]
+	}
+
+	for {
+		top := s.top.Load()
+		nodes[0].next = top
+
+		for i, value := range values {
+			nodes[i].value = value
+			nodes[i].size = nodeSize(top) + i + 1
+		}
+
+		if s.top.CompareAndSwap(top, &nodes[len(nodes)-1]) {
+			break
+		}
+	}
+
+	s.notify()
+}
+
+func (s *treiberStack[T]) TryPop() (T, bool) {
+	for {
+		top := s.top.Load()
+		if top == nil {
+			var zero T
+			return zero, false
+		}
+
+		if s.top.CompareAndSwap(top, top.next) {
+			return top.value, true
+		}
+	}
+}
+
+func (s *treiberStack[T]) Pop(ctx context.Context) (T, error) {
+	for {
+		if ctx.Err() != nil {
+			var zero T
+			return zero, contextError(ctx)
+		}
+
+		if value, ok := s.TryPop(); ok {
+			return value, nil
+		}
+
+		if err := s.wait(ctx, 1); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+func (s *treiberStack[T]) TryPopN(n int) ([]T, bool) {
+	if n <= 0 {
+		return nil, true
+	}
+
+	for {
+		top := s.top.Load()
+		if nodeSize(top) < n {
+			return nil, false
+		}
+
+		values := make([]T, 0, n)
+		el := top
+		for range n {
+			values = append(values, el.value)
+			el = el.next
+		}
+
+		if s.top.CompareAndSwap(top, el) {
+			return values, true
+		}
+	}
+}
+
+func (s *treiberStack[T]) PopN(ctx context.Context, n int) ([]T, error) {
+	for {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
+
+		if values, ok := s.TryPopN(n); ok {
+			return values, nil
+		}
+
+		if err := s.wait(ctx, n); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *treiberStack[T]) TryPeek() (T, bool) {
+	top := s.top.Load()
+	if top == nil {
+		var zero T
+		return zero, false
+	}
+	return top.value, true
+}
+
+func (s *treiberStack[T]) Peek(ctx context.Context) (T, error) {
+	for {
+		if ctx.Err() != nil {
+			var zero T
+			return zero, contextError(ctx)
+		}
+
+		if value, ok := s.TryPeek(); ok {
+			return value, nil
+		}
+
+		if err := s.wait(ctx, 1); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+func nodeSize[T any](el *treiberNode[T]) int {
+	if el == nil {
+		return 0
+	}
+	return el.size
+}
+
+func nodeValues[T any](el *treiberNode[T], n int) []T {
+	values := make([]T, 0, max(min(n, nodeSize(el)), 0))
+	for ; el != nil && len(values) < n; el = el.next {
+		values = append(values, el.value)
+	}
+	return values
+}
+
+func (s *treiberStack[T]) PeekN(n int) []T {
+	return nodeValues(s.top.Load(), n)
+}
+
+func (s *treiberStack[T]) Snapshot() []T {
+	top := s.top.Load()
+	return nodeValues(top, nodeSize(top))
+}
+
+func (s *treiberStack[T]) Drain() []T {
+	top := s.top.Swap(nil)
+	return nodeValues(top, nodeSize(top))
+}
+
+func (s *treiberStack[T]) Clear() {
+	s.top.Store(nil)
+}
+
+func (s *treiberStack[T]) Size() int {
+	return nodeSize(s.top.Load())
+}
+
+func (s *treiberStack[T]) IsEmpty() bool {
+	return s.top.Load() == nil
+}
+
+func newTreiber[T any]() *treiberStack[T] {
+	return &treiberStack[T]{wake: make(chan struct{})}
+}